@@ -0,0 +1,62 @@
+package main
+
+import (
+	"math/rand"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+// aiUserID is the user ID the AI player is seated with in a match.
+const aiUserID = "ai-user-id"
+
+// aiPresence is the presence of the AI player. It isn't connected to the match, so
+// messages broadcast to it go nowhere.
+type aiPresence struct{}
+
+func (aiPresence) GetHidden() bool                   { return true }
+func (aiPresence) GetPersistence() bool              { return false }
+func (aiPresence) GetUsername() string               { return "AI" }
+func (aiPresence) GetStatus() string                 { return "" }
+func (aiPresence) GetReason() runtime.PresenceReason { return runtime.PresenceReasonUnknown }
+func (aiPresence) GetUserId() string                 { return aiUserID }
+func (aiPresence) GetSessionId() string              { return "" }
+func (aiPresence) GetNodeId() string                 { return "" }
+
+// aiMove picks the move the AI plays for the mark. It wins when it can, blocks the
+// opponent's winning move otherwise, then prefers the moves closest to the center.
+func aiMove(random *rand.Rand, rules gameRules, board []api.Mark, mark api.Mark) int32 {
+	moves := rules.legalMoves(board)
+	trial := make([]api.Mark, len(board))
+	for _, candidate := range []api.Mark{mark, opponentMark(mark)} {
+		for _, move := range moves {
+			copy(trial, board)
+			if cell, err := rules.apply(trial, move, candidate); err == nil && rules.winningLine(trial, cell) != nil {
+				return move
+			}
+		}
+	}
+
+	var closest []int32
+	closestDistance := 0
+	for _, move := range moves {
+		copy(trial, board)
+		cell, err := rules.apply(trial, move, mark)
+		if err != nil {
+			continue
+		}
+		distance := rules.centerDistance(cell)
+		if len(closest) > 0 && distance > closestDistance {
+			continue
+		}
+		if len(closest) == 0 || distance < closestDistance {
+			closest, closestDistance = closest[:0], distance
+		}
+		closest = append(closest, move)
+	}
+	if len(closest) == 0 {
+		return -1
+	}
+	return closest[random.Intn(len(closest))]
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// checksCollectionName holds one object per user, manifest type and version with the
+// time the user last checked it. Objects are only readable and writable by the server.
+const checksCollectionName string = "ZeptoLabVersionChecks"
+
+// defaultAdoptionWindow is used when the adoption request doesn't specify a window.
+const defaultAdoptionWindow = 24 * time.Hour
+
+// VersionCheck is the per-user record of a version check.
+type VersionCheck struct {
+	Type      string `json:"type"`
+	Version   string `json:"version"`
+	Variant   string `json:"variant"`
+	CheckedAt int64  `json:"checked_at"`
+}
+
+// userVersionCheck is a version check paired with the user who made it.
+type userVersionCheck struct {
+	UserID string
+	VersionCheck
+}
+
+// AdoptionRequest represents the payload of the VersionAdoption RPC.
+type AdoptionRequest struct {
+	// Type limits the report to one manifest type, all types are reported when empty.
+	Type string `json:"type"`
+	// Hours and Days add up to the reporting window, 24 hours by default.
+	Hours int `json:"hours"`
+	Days  int `json:"days"`
+	// Deprecated lists the versions which are scheduled to be dropped.
+	Deprecated []string `json:"deprecated"`
+}
+
+// AdoptionResponse represents the response of the VersionAdoption RPC.
+type AdoptionResponse struct {
+	Since int64           `json:"since"`
+	Types []*TypeAdoption `json:"types"`
+}
+
+// TypeAdoption is the version distribution of a single manifest type.
+type TypeAdoption struct {
+	Type            string          `json:"type"`
+	Users           int             `json:"users"`
+	DeprecatedUsers int             `json:"deprecated_users"`
+	DeprecatedShare float64         `json:"deprecated_share"`
+	Versions        []*VersionShare `json:"versions"`
+}
+
+// VersionShare is the number of unique users who checked a version.
+type VersionShare struct {
+	Version    string  `json:"version"`
+	Users      int     `json:"users"`
+	Share      float64 `json:"share"`
+	Deprecated bool    `json:"deprecated"`
+}
+
+// recordUserCheck remembers that the calling user checked a manifest version. Calls
+// made with the server key carry no user ID and aren't recorded.
+func recordUserCheck(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, p Payload, variant string) {
+	userID := contextUserID(ctx)
+	if userID == "" {
+		return
+	}
+
+	value, err := json.Marshal(VersionCheck{
+		Type:      p.Type,
+		Version:   p.Version,
+		Variant:   variant,
+		CheckedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		logger.Error("failed to marshal version check: %s", err)
+		return
+	}
+	if _, err := nk.StorageWrite(ctx, []*runtime.StorageWrite{{
+		Collection:      checksCollectionName,
+		Key:             fmt.Sprintf("%s/%s", p.Type, p.Version),
+		UserID:          userID,
+		Value:           string(value),
+		PermissionRead:  0, // No client read.
+		PermissionWrite: 0, // No client write.
+	}}); err != nil {
+		logger.Error("failed to record version check: %s", err)
+	}
+}
+
+func (r *AdoptionRequest) validate() error {
+	if r.Hours < 0 || r.Days < 0 {
+		return errors.New("window must not be negative")
+	}
+	return nil
+}
+
+// VersionAdoption reports, per manifest type, which versions users checked within a
+// time window and how many of them are still on deprecated versions. Only admins can
+// call it.
+var VersionAdoption = newRpc("VersionAdoption", versionAdoption)
+
+func versionAdoption(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, request *AdoptionRequest) (*AdoptionResponse, error) {
+	window := time.Duration(request.Hours)*time.Hour + time.Duration(request.Days)*24*time.Hour
+	if window == 0 {
+		window = defaultAdoptionWindow
+	}
+	since := time.Now().Add(-window).Unix()
+
+	var checks []*userVersionCheck
+	cursor := ""
+	for {
+		var objects []*api.StorageObject
+		var err error
+		objects, cursor, err = nk.StorageList(ctx, "", checksCollectionName, 100, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list version checks: %s", err)
+		}
+		for _, object := range objects {
+			check := &userVersionCheck{UserID: object.UserId}
+			if err := json.Unmarshal([]byte(object.Value), &check.VersionCheck); err != nil {
+				logger.Warn("skipping malformed version check %s of user %s: %s", object.Key, object.UserId, err)
+				continue
+			}
+			checks = append(checks, check)
+		}
+		if cursor == "" {
+			break
+		}
+	}
+
+	return &AdoptionResponse{
+		Since: since,
+		Types: aggregateAdoption(checks, since, request.Type, request.Deprecated),
+	}, nil
+}
+
+// aggregateAdoption builds the per type version distribution of the checks made at or
+// after since. A user counts towards every version they checked in the window, but
+// only their most recent check decides whether they are still on a deprecated version.
+func aggregateAdoption(checks []*userVersionCheck, since int64, manifestType string, deprecated []string) []*TypeAdoption {
+	deprecatedVersions := make(map[string]bool, len(deprecated))
+	for _, version := range deprecated {
+		deprecatedVersions[version] = true
+	}
+
+	versionUsers := make(map[string]map[string]int)
+	latest := make(map[string]map[string]*userVersionCheck)
+	for _, check := range checks {
+		if check.CheckedAt < since || (manifestType != "" && check.Type != manifestType) {
+			continue
+		}
+		if versionUsers[check.Type] == nil {
+			versionUsers[check.Type] = make(map[string]int)
+			latest[check.Type] = make(map[string]*userVersionCheck)
+		}
+		versionUsers[check.Type][check.Version]++
+		if last, found := latest[check.Type][check.UserID]; !found || check.CheckedAt > last.CheckedAt {
+			latest[check.Type][check.UserID] = check
+		}
+	}
+
+	adoption := make([]*TypeAdoption, 0, len(versionUsers))
+	for checkType, versions := range versionUsers {
+		typeAdoption := &TypeAdoption{
+			Type:     checkType,
+			Users:    len(latest[checkType]),
+			Versions: make([]*VersionShare, 0, len(versions)),
+		}
+		for _, check := range latest[checkType] {
+			if deprecatedVersions[check.Version] {
+				typeAdoption.DeprecatedUsers++
+			}
+		}
+		typeAdoption.DeprecatedShare = float64(typeAdoption.DeprecatedUsers) / float64(typeAdoption.Users)
+		for version, users := range versions {
+			typeAdoption.Versions = append(typeAdoption.Versions, &VersionShare{
+				Version:    version,
+				Users:      users,
+				Share:      float64(users) / float64(typeAdoption.Users),
+				Deprecated: deprecatedVersions[version],
+			})
+		}
+		sort.Slice(typeAdoption.Versions, func(i, j int) bool {
+			return typeAdoption.Versions[i].Version < typeAdoption.Versions[j].Version
+		})
+		adoption = append(adoption, typeAdoption)
+	}
+	sort.Slice(adoption, func(i, j int) bool {
+		return adoption[i].Type < adoption[j].Type
+	})
+	return adoption
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateAdoption(t *testing.T) {
+	t.Parallel()
+	check := func(userID, checkType, version string, checkedAt int64) *userVersionCheck {
+		return &userVersionCheck{UserID: userID, VersionCheck: VersionCheck{Type: checkType, Version: version, CheckedAt: checkedAt}}
+	}
+	checks := []*userVersionCheck{
+		check("user1", "core", "1.0.0", 100),
+		check("user1", "core", "1.1.0", 200), // user1 upgraded within the window
+		check("user2", "core", "1.0.0", 150),
+		check("user3", "core", "1.1.0", 50), // outside the window
+		check("user1", "levels", "2.0.0", 120),
+	}
+
+	adoption := aggregateAdoption(checks, 100, "", []string{"1.0.0"})
+	if !assert.Len(t, adoption, 2) {
+		return
+	}
+
+	core := adoption[0]
+	assert.Equal(t, "core", core.Type)
+	assert.Equal(t, 2, core.Users)
+	assert.Equal(t, 1, core.DeprecatedUsers, "Expected only user2 to still be on a deprecated version")
+	assert.Equal(t, 0.5, core.DeprecatedShare)
+	if assert.Len(t, core.Versions, 2) {
+		assert.Equal(t, &VersionShare{Version: "1.0.0", Users: 2, Share: 1, Deprecated: true}, core.Versions[0])
+		assert.Equal(t, &VersionShare{Version: "1.1.0", Users: 1, Share: 0.5}, core.Versions[1])
+	}
+	assert.Equal(t, "levels", adoption[1].Type)
+
+	adoption = aggregateAdoption(checks, 100, "levels", nil)
+	if assert.Len(t, adoption, 1) {
+		assert.Equal(t, "levels", adoption[0].Type)
+		assert.Zero(t, adoption[0].DeprecatedShare)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+const (
+	// messageRate and messageBurst limit the messages a player or spectator can send, in
+	// messages per second and in a quick succession. They're counted in match time.
+	messageRate  = 2
+	messageBurst = 5
+	// maxViolations is the number of violations which gets the sender kicked out of the
+	// match for good.
+	maxViolations = 10
+	// auditCollectionName holds one object per kicked user and match, keyed by match ID
+	// and user ID and owned by the system user. Objects are only readable by the server.
+	auditCollectionName = "xoxo_audit"
+)
+
+var (
+	errMalformedMessage   = errors.New("malformed message")
+	errNotYourTurn        = errors.New("not your turn")
+	errGameOver           = errors.New("no round in progress")
+	errMessageRateLimited = errors.New("too many messages")
+	errUnexpectedMessage  = errors.New("unexpected message")
+	errCannotInviteAI     = errors.New("can't invite the AI")
+)
+
+// AuditEntry is the record of a user kicked out of a match for repeated violations.
+type AuditEntry struct {
+	MatchID   string `json:"match_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+	// Violations counts the violations of the user by reason.
+	Violations map[string]int `json:"violations"`
+	Tick       int64          `json:"tick"`
+	KickedAt   int64          `json:"kicked_at"`
+}
+
+// rejectReason returns the reason sent to clients for a rejected message.
+func rejectReason(err error) api.RejectReason {
+	switch {
+	case errors.Is(err, errMalformedMessage):
+		return api.RejectReason_REJECT_REASON_MALFORMED
+	case errors.Is(err, errNotYourTurn):
+		return api.RejectReason_REJECT_REASON_NOT_YOUR_TURN
+	case errors.Is(err, errMoveOutOfRange):
+		return api.RejectReason_REJECT_REASON_OUT_OF_RANGE
+	case errors.Is(err, errCellOccupied):
+		return api.RejectReason_REJECT_REASON_OCCUPIED
+	case errors.Is(err, errGameOver):
+		return api.RejectReason_REJECT_REASON_GAME_OVER
+	case errors.Is(err, errMessageRateLimited):
+		return api.RejectReason_REJECT_REASON_RATE_LIMITED
+	default:
+		return api.RejectReason_REJECT_REASON_UNSPECIFIED
+	}
+}
+
+// rejection returns the rejection sent for a message, with the position of the move
+// when the message is a move that parses.
+func rejection(message runtime.MatchData, err error) *api.Rejected {
+	rejected := &api.Rejected{Reason: rejectReason(err)}
+	move := &api.Move{}
+	if api.OpCode(message.GetOpCode()) == api.OpCode_OPCODE_MOVE && proto.Unmarshal(message.GetData(), move) == nil {
+		rejected.Position = proto.Int32(move.Position)
+	}
+	return rejected
+}
+
+// newMessageLimiter returns the limiter of the messages sent in a match, which runs on
+// the match loop ticks rather than on the wall clock.
+func newMessageLimiter(s *MatchState) *rateLimiter {
+	limiter := newRateLimiter(messageRate, messageBurst)
+	limiter.now = func() time.Time {
+		return time.Unix(0, 0).Add(time.Duration(s.tick) * time.Second / tickRate)
+	}
+	return limiter
+}
+
+// isViolation reports whether a message was rejected for something an honest client
+// doesn't send. Moves out of turn, on a taken position or after the round ended can
+// just be late, so they don't count.
+func isViolation(err error) bool {
+	return errors.Is(err, errMalformedMessage) || errors.Is(err, errMoveOutOfRange) ||
+		errors.Is(err, errMessageRateLimited) || errors.Is(err, errUnexpectedMessage)
+}
+
+// addViolation counts a violation of the user and reports whether they reached the
+// number of violations they're kicked for.
+func (s *MatchState) addViolation(userID string, reason api.RejectReason) bool {
+	if s.violations[userID] == nil {
+		s.violations[userID] = make(map[string]int)
+	}
+	s.violations[userID][reason.String()]++
+	total := 0
+	for _, count := range s.violations[userID] {
+		total += count
+	}
+	return total >= maxViolations
+}
+
+// kick removes the sender of too many violations from the match, keeps them from
+// joining again and records why in the audit collection.
+func (m *MatchHandler) kick(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, dispatcher runtime.MatchDispatcher, s *MatchState, presence runtime.Presence) {
+	userID := presence.GetUserId()
+	s.kicked[userID] = true
+	// The kicked player loses the round right away rather than when Nakama reports them gone.
+	if mark, ok := s.marks[userID]; ok && s.playing {
+		m.forfeitRound(logger, dispatcher, s, mark)
+	}
+	if err := dispatcher.MatchKick([]runtime.Presence{presence}); err != nil {
+		logger.Error("error kicking %s: %v", userID, err)
+	}
+
+	entry := &AuditEntry{
+		MatchID:    s.matchID,
+		UserID:     userID,
+		SessionID:  presence.GetSessionId(),
+		Violations: s.violations[userID],
+		Tick:       s.tick,
+		KickedAt:   time.Now().Unix(),
+	}
+	logger.WithFields(map[string]interface{}{
+		"user_id":    userID,
+		"violations": entry.Violations,
+	}).Warn("kicked user for repeated violations")
+
+	value, err := json.Marshal(entry)
+	if err != nil {
+		logger.Error("failed to marshal audit entry: %s", err)
+		return
+	}
+	if _, err := nk.StorageWrite(ctx, []*runtime.StorageWrite{{
+		Collection:      auditCollectionName,
+		Key:             fmt.Sprintf("%s/%s", s.matchID, userID),
+		UserID:          currentConfig().SystemUserID,
+		Value:           string(value),
+		PermissionRead:  0, // No client read.
+		PermissionWrite: 0, // No client write.
+	}}); err != nil {
+		logger.Error("failed to save audit entry: %s", err)
+	}
+}
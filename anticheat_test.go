@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+// lastRejected returns the last rejection sent.
+func (m *testMatch) lastRejected() *api.Rejected {
+	broadcast := m.dispatcher.last(api.OpCode_OPCODE_REJECTED)
+	if broadcast == nil {
+		m.t.Fatal("Expected a rejection")
+	}
+	rejected := &api.Rejected{}
+	if err := proto.Unmarshal(broadcast.data, rejected); err != nil {
+		m.t.Fatalf("Failed to unmarshal rejection: %v", err)
+	}
+	return rejected
+}
+
+// lastRejection returns the reason of the last rejection sent.
+func (m *testMatch) lastRejection() api.RejectReason {
+	return m.lastRejected().Reason
+}
+
+func TestMatchHandlerRejectReasons(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, nil)
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+
+	m.loop(&testMatchData{testPresence: testPresence{userID: x}, opCode: int64(api.OpCode_OPCODE_MOVE), data: []byte{0xff}})
+	assert.Equal(t, api.RejectReason_REJECT_REASON_MALFORMED, m.lastRejection())
+	m.loop(m.move(o, 0))
+	assert.Equal(t, api.RejectReason_REJECT_REASON_NOT_YOUR_TURN, m.lastRejection())
+	m.loop(m.move(x, 9))
+	assert.Equal(t, api.RejectReason_REJECT_REASON_OUT_OF_RANGE, m.lastRejection())
+	assert.Equal(t, int32(9), m.lastRejected().GetPosition())
+	m.loop(&testMatchData{testPresence: testPresence{userID: x}, opCode: int64(api.OpCode_OPCODE_START)})
+	assert.Equal(t, api.RejectReason_REJECT_REASON_UNSPECIFIED, m.lastRejection())
+	assert.Nil(t, m.lastRejected().Position, "Expected no position for other messages")
+	assert.Len(t, m.matchState().violations[x], 3)
+	assert.Empty(t, m.matchState().violations[o], "Expected moves out of turn not to count as violations")
+	assert.Equal(t, 0, countMarks(m.matchState().board))
+
+	// A burst of messages is let through, the rest is rejected until the limit refills.
+	var messages []runtime.MatchData
+	for i := 0; i <= messageBurst; i++ {
+		messages = append(messages, m.move(o, 0))
+	}
+	m.loop(messages...)
+	assert.Equal(t, api.RejectReason_REJECT_REASON_RATE_LIMITED, m.lastRejection())
+	m.loop(m.move(x, 0))
+	assert.Equal(t, api.Mark_MARK_X, m.matchState().board[0], "Expected other players not to be limited")
+	m.tick += messageBurst * tickRate
+	m.loop(m.move(o, 0))
+	assert.Equal(t, api.RejectReason_REJECT_REASON_OCCUPIED, m.lastRejection())
+	assert.Equal(t, int32(0), m.lastRejected().GetPosition())
+}
+
+func TestMatchHandlerRejectGameOver(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, nil)
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+	m.loop(m.move(x, 0), m.move(o, 3), m.move(x, 1), m.move(o, 4), m.move(x, 2))
+	assert.False(t, m.matchState().playing)
+
+	m.loop(m.move(o, 5))
+	assert.Equal(t, api.RejectReason_REJECT_REASON_GAME_OVER, m.lastRejection())
+	assert.Equal(t, int32(5), m.lastRejected().GetPosition())
+}
+
+func TestMatchHandlerKick(t *testing.T) {
+	t.Parallel()
+	nk := newStorageTestNakamaModule()
+	m := newTestMatch(t, nil)
+	m.nk = nk
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+
+	// Late moves are rejected without counting towards a kick.
+	for i := 0; i < maxViolations; i++ {
+		m.tick += tickRate
+		m.loop(m.move(o, 0))
+	}
+	assert.Empty(t, m.dispatcher.kicked)
+
+	for i := 0; i < maxViolations-1; i++ {
+		m.tick += tickRate
+		m.loop(m.move(x, 9))
+	}
+	assert.Empty(t, m.dispatcher.kicked)
+	m.tick += tickRate
+	m.loop(m.move(x, 9), m.move(x, 9))
+	if assert.Len(t, m.dispatcher.kicked, 1) {
+		assert.Equal(t, x, m.dispatcher.kicked[0].GetUserId())
+	}
+	assert.Equal(t, maxViolations, m.matchState().violations[x][api.RejectReason_REJECT_REASON_OUT_OF_RANGE.String()], "Expected messages after the kick to be dropped")
+	assert.Equal(t, m.matchState().marks[o], m.lastDone().Winner, "Expected the kicked player to forfeit the round")
+
+	object, ok := nk.objects[auditCollectionName+"/"+currentConfig().SystemUserID+"/match-id.node/"+x]
+	if assert.True(t, ok, "Expected an audit entry") {
+		entry := &AuditEntry{}
+		assert.NoError(t, json.Unmarshal([]byte(object.Value), entry))
+		assert.Equal(t, x, entry.UserID)
+		assert.Equal(t, maxViolations, entry.Violations[api.RejectReason_REJECT_REASON_OUT_OF_RANGE.String()])
+	}
+
+	// Nakama calls MatchLeave for the kicked presence.
+	m.leave(x)
+	assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_OPPONENT_LEFT), "Expected the round to end without a grace window")
+	assert.Empty(t, m.matchState().disconnected)
+	accepted, reason := m.join(x, nil)
+	assert.False(t, accepted)
+	assert.Equal(t, "kicked", reason)
+}
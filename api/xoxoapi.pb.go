@@ -162,6 +162,75 @@ func (OpCode) EnumDescriptor() ([]byte, []int) {
 	return file_xoxoapi_proto_rawDescGZIP(), []int{1}
 }
 
+// The reasons a client message is rejected for.
+type RejectReason int32
+
+const (
+	// No specific reason, e.g. an opcode the server doesn't expect.
+	RejectReason_REJECT_REASON_UNSPECIFIED RejectReason = 0
+	// The payload couldn't be parsed.
+	RejectReason_REJECT_REASON_MALFORMED RejectReason = 1
+	// It's not the sender's turn to play.
+	RejectReason_REJECT_REASON_NOT_YOUR_TURN RejectReason = 2
+	// The position is outside of the board.
+	RejectReason_REJECT_REASON_OUT_OF_RANGE RejectReason = 3
+	// The sender sent messages faster than allowed.
+	RejectReason_REJECT_REASON_RATE_LIMITED RejectReason = 4
+	// The position is already taken, or for Connect Four the column is full.
+	RejectReason_REJECT_REASON_OCCUPIED RejectReason = 5
+	// No round is in progress, the last one is over.
+	RejectReason_REJECT_REASON_GAME_OVER RejectReason = 6
+)
+
+// Enum value maps for RejectReason.
+var (
+	RejectReason_name = map[int32]string{
+		0: "REJECT_REASON_UNSPECIFIED",
+		1: "REJECT_REASON_MALFORMED",
+		2: "REJECT_REASON_NOT_YOUR_TURN",
+		3: "REJECT_REASON_OUT_OF_RANGE",
+		4: "REJECT_REASON_RATE_LIMITED",
+		5: "REJECT_REASON_OCCUPIED",
+		6: "REJECT_REASON_GAME_OVER",
+	}
+	RejectReason_value = map[string]int32{
+		"REJECT_REASON_UNSPECIFIED":   0,
+		"REJECT_REASON_MALFORMED":     1,
+		"REJECT_REASON_NOT_YOUR_TURN": 2,
+		"REJECT_REASON_OUT_OF_RANGE":  3,
+		"REJECT_REASON_RATE_LIMITED":  4,
+		"REJECT_REASON_OCCUPIED":      5,
+		"REJECT_REASON_GAME_OVER":     6,
+	}
+)
+
+func (x RejectReason) Enum() *RejectReason {
+	p := new(RejectReason)
+	*p = x
+	return p
+}
+
+func (x RejectReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RejectReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_xoxoapi_proto_enumTypes[2].Descriptor()
+}
+
+func (RejectReason) Type() protoreflect.EnumType {
+	return &file_xoxoapi_proto_enumTypes[2]
+}
+
+func (x RejectReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RejectReason.Descriptor instead.
+func (RejectReason) EnumDescriptor() ([]byte, []int) {
+	return file_xoxoapi_proto_rawDescGZIP(), []int{2}
+}
+
 // Message data sent by server to clients representing a new game round starting.
 type Start struct {
 	state         protoimpl.MessageState
@@ -176,6 +245,12 @@ type Start struct {
 	Mark Mark `protobuf:"varint,3,opt,name=mark,proto3,enum=api.Mark" json:"mark,omitempty"`
 	// The deadline time by which the player must submit their move, or forfeit.
 	Deadline int64 `protobuf:"varint,4,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	// The rounds won in the series so far, by user ID.
+	Scores map[string]int32 `protobuf:"bytes,5,rep,name=scores,proto3" json:"scores,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// The number of this round in the series, starting at 1.
+	Round int32 `protobuf:"varint,6,opt,name=round,proto3" json:"round,omitempty"`
+	// The number of rounds the series is played over, won by the first player to win a majority.
+	BestOf int32 `protobuf:"varint,7,opt,name=best_of,json=bestOf,proto3" json:"best_of,omitempty"`
 }
 
 func (x *Start) Reset() {
@@ -238,6 +313,27 @@ func (x *Start) GetDeadline() int64 {
 	return 0
 }
 
+func (x *Start) GetScores() map[string]int32 {
+	if x != nil {
+		return x.Scores
+	}
+	return nil
+}
+
+func (x *Start) GetRound() int32 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *Start) GetBestOf() int32 {
+	if x != nil {
+		return x.BestOf
+	}
+	return 0
+}
+
 // A game state update sent by the server to clients.
 type Update struct {
 	state         protoimpl.MessageState
@@ -320,6 +416,12 @@ type Done struct {
 	WinnerPositions []int32 `protobuf:"varint,3,rep,packed,name=winner_positions,json=winnerPositions,proto3" json:"winner_positions,omitempty"`
 	// Next round start time.
 	NextGameStart int64 `protobuf:"varint,4,opt,name=next_game_start,json=nextGameStart,proto3" json:"next_game_start,omitempty"`
+	// The rounds won in the series so far including this one, by user ID.
+	Scores map[string]int32 `protobuf:"bytes,5,rep,name=scores,proto3" json:"scores,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// Whether this round completed the series. The next round starts a new series.
+	SeriesDone bool `protobuf:"varint,6,opt,name=series_done,json=seriesDone,proto3" json:"series_done,omitempty"`
+	// The user ID of the winner of the series once it's done. Empty if the series is a draw.
+	SeriesWinner string `protobuf:"bytes,7,opt,name=series_winner,json=seriesWinner,proto3" json:"series_winner,omitempty"`
 }
 
 func (x *Done) Reset() {
@@ -382,6 +484,27 @@ func (x *Done) GetNextGameStart() int64 {
 	return 0
 }
 
+func (x *Done) GetScores() map[string]int32 {
+	if x != nil {
+		return x.Scores
+	}
+	return nil
+}
+
+func (x *Done) GetSeriesDone() bool {
+	if x != nil {
+		return x.SeriesDone
+	}
+	return false
+}
+
+func (x *Done) GetSeriesWinner() string {
+	if x != nil {
+		return x.SeriesWinner
+	}
+	return ""
+}
+
 // A player intends to make a move.
 type Move struct {
 	state         protoimpl.MessageState
@@ -431,6 +554,64 @@ func (x *Move) GetPosition() int32 {
 	return 0
 }
 
+// A message of the player was rejected.
+type Rejected struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Why the message was rejected.
+	Reason RejectReason `protobuf:"varint,1,opt,name=reason,proto3,enum=api.RejectReason" json:"reason,omitempty"`
+	// The position of the rejected move. Unset when the message isn't a move or doesn't parse.
+	Position *int32 `protobuf:"varint,2,opt,name=position,proto3,oneof" json:"position,omitempty"`
+}
+
+func (x *Rejected) Reset() {
+	*x = Rejected{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_xoxoapi_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Rejected) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rejected) ProtoMessage() {}
+
+func (x *Rejected) ProtoReflect() protoreflect.Message {
+	mi := &file_xoxoapi_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rejected.ProtoReflect.Descriptor instead.
+func (*Rejected) Descriptor() ([]byte, []int) {
+	return file_xoxoapi_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Rejected) GetReason() RejectReason {
+	if x != nil {
+		return x.Reason
+	}
+	return RejectReason_REJECT_REASON_UNSPECIFIED
+}
+
+func (x *Rejected) GetPosition() int32 {
+	if x != nil && x.Position != nil {
+		return *x.Position
+	}
+	return 0
+}
+
 // Payload for an RPC request to find a match.
 type RpcFindMatchRequest struct {
 	state         protoimpl.MessageState
@@ -446,7 +627,7 @@ type RpcFindMatchRequest struct {
 func (x *RpcFindMatchRequest) Reset() {
 	*x = RpcFindMatchRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_xoxoapi_proto_msgTypes[4]
+		mi := &file_xoxoapi_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -459,7 +640,7 @@ func (x *RpcFindMatchRequest) String() string {
 func (*RpcFindMatchRequest) ProtoMessage() {}
 
 func (x *RpcFindMatchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_xoxoapi_proto_msgTypes[4]
+	mi := &file_xoxoapi_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -472,7 +653,7 @@ func (x *RpcFindMatchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RpcFindMatchRequest.ProtoReflect.Descriptor instead.
 func (*RpcFindMatchRequest) Descriptor() ([]byte, []int) {
-	return file_xoxoapi_proto_rawDescGZIP(), []int{4}
+	return file_xoxoapi_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *RpcFindMatchRequest) GetFast() bool {
@@ -502,7 +683,7 @@ type RpcFindMatchResponse struct {
 func (x *RpcFindMatchResponse) Reset() {
 	*x = RpcFindMatchResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_xoxoapi_proto_msgTypes[5]
+		mi := &file_xoxoapi_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -515,7 +696,7 @@ func (x *RpcFindMatchResponse) String() string {
 func (*RpcFindMatchResponse) ProtoMessage() {}
 
 func (x *RpcFindMatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_xoxoapi_proto_msgTypes[5]
+	mi := &file_xoxoapi_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -528,7 +709,7 @@ func (x *RpcFindMatchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RpcFindMatchResponse.ProtoReflect.Descriptor instead.
 func (*RpcFindMatchResponse) Descriptor() ([]byte, []int) {
-	return file_xoxoapi_proto_rawDescGZIP(), []int{5}
+	return file_xoxoapi_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *RpcFindMatchResponse) GetMatchIds() []string {
@@ -542,7 +723,7 @@ var File_xoxoapi_proto protoreflect.FileDescriptor
 
 var file_xoxoapi_proto_rawDesc = []byte{
 	0x0a, 0x0d, 0x78, 0x6f, 0x78, 0x6f, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
-	0x03, 0x61, 0x70, 0x69, 0x22, 0xd5, 0x01, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x1f,
+	0x03, 0x61, 0x70, 0x69, 0x22, 0xef, 0x02, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x1f,
 	0x0a, 0x05, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x09, 0x2e,
 	0x61, 0x70, 0x69, 0x2e, 0x4d, 0x61, 0x72, 0x6b, 0x52, 0x05, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x12,
 	0x2b, 0x0a, 0x05, 0x6d, 0x61, 0x72, 0x6b, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15,
@@ -551,55 +732,96 @@ var file_xoxoapi_proto_rawDesc = []byte{
 	0x6d, 0x61, 0x72, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69,
 	0x2e, 0x4d, 0x61, 0x72, 0x6b, 0x52, 0x04, 0x6d, 0x61, 0x72, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x64,
 	0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x64,
-	0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x1a, 0x43, 0x0a, 0x0a, 0x4d, 0x61, 0x72, 0x6b, 0x73,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x1f, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x61, 0x72,
-	0x6b, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x64, 0x0a, 0x06,
-	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x1f, 0x0a, 0x05, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x18,
-	0x01, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x61, 0x72, 0x6b,
-	0x52, 0x05, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x12, 0x1d, 0x0a, 0x04, 0x6d, 0x61, 0x72, 0x6b, 0x18,
+	0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x2e, 0x0a, 0x06, 0x73, 0x63, 0x6f, 0x72, 0x65,
+	0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
+	0x06, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x17, 0x0a,
+	0x07, 0x62, 0x65, 0x73, 0x74, 0x5f, 0x6f, 0x66, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06,
+	0x62, 0x65, 0x73, 0x74, 0x4f, 0x66, 0x1a, 0x43, 0x0a, 0x0a, 0x4d, 0x61, 0x72, 0x6b, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x1f, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
 	0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x61, 0x72, 0x6b,
-	0x52, 0x04, 0x6d, 0x61, 0x72, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69,
-	0x6e, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69,
-	0x6e, 0x65, 0x22, 0x9d, 0x01, 0x0a, 0x04, 0x44, 0x6f, 0x6e, 0x65, 0x12, 0x1f, 0x0a, 0x05, 0x62,
-	0x6f, 0x61, 0x72, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69,
-	0x2e, 0x4d, 0x61, 0x72, 0x6b, 0x52, 0x05, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x12, 0x21, 0x0a, 0x06,
-	0x77, 0x69, 0x6e, 0x6e, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x09, 0x2e, 0x61,
-	0x70, 0x69, 0x2e, 0x4d, 0x61, 0x72, 0x6b, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x6e, 0x65, 0x72, 0x12,
-	0x29, 0x0a, 0x10, 0x77, 0x69, 0x6e, 0x6e, 0x65, 0x72, 0x5f, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69,
-	0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x05, 0x52, 0x0f, 0x77, 0x69, 0x6e, 0x6e, 0x65,
-	0x72, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65,
-	0x78, 0x74, 0x5f, 0x67, 0x61, 0x6d, 0x65, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x04, 0x20,
-	0x01, 0x28, 0x03, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61,
-	0x72, 0x74, 0x22, 0x22, 0x0a, 0x04, 0x4d, 0x6f, 0x76, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6f,
-	0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x6f,
-	0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x39, 0x0a, 0x13, 0x52, 0x70, 0x63, 0x46, 0x69, 0x6e,
-	0x64, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a,
-	0x04, 0x66, 0x61, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x66, 0x61, 0x73,
-	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x61, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x61,
-	0x69, 0x22, 0x33, 0x0a, 0x14, 0x52, 0x70, 0x63, 0x46, 0x69, 0x6e, 0x64, 0x4d, 0x61, 0x74, 0x63,
-	0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x74,
-	0x63, 0x68, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x6d, 0x61,
-	0x74, 0x63, 0x68, 0x49, 0x64, 0x73, 0x2a, 0x34, 0x0a, 0x04, 0x4d, 0x61, 0x72, 0x6b, 0x12, 0x14,
-	0x0a, 0x10, 0x4d, 0x41, 0x52, 0x4b, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
-	0x45, 0x44, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x4d, 0x41, 0x52, 0x4b, 0x5f, 0x58, 0x10, 0x01,
-	0x12, 0x0a, 0x0a, 0x06, 0x4d, 0x41, 0x52, 0x4b, 0x5f, 0x4f, 0x10, 0x02, 0x2a, 0xac, 0x01, 0x0a,
-	0x06, 0x4f, 0x70, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x0a, 0x12, 0x4f, 0x50, 0x43, 0x4f, 0x44,
-	0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
-	0x10, 0x0a, 0x0c, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54, 0x10,
-	0x01, 0x12, 0x11, 0x0a, 0x0d, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x50, 0x44, 0x41,
-	0x54, 0x45, 0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x44,
-	0x4f, 0x4e, 0x45, 0x10, 0x03, 0x12, 0x0f, 0x0a, 0x0b, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f,
-	0x4d, 0x4f, 0x56, 0x45, 0x10, 0x04, 0x12, 0x13, 0x0a, 0x0f, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45,
-	0x5f, 0x52, 0x45, 0x4a, 0x45, 0x43, 0x54, 0x45, 0x44, 0x10, 0x05, 0x12, 0x18, 0x0a, 0x14, 0x4f,
-	0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x4f, 0x50, 0x50, 0x4f, 0x4e, 0x45, 0x4e, 0x54, 0x5f, 0x4c,
-	0x45, 0x46, 0x54, 0x10, 0x06, 0x12, 0x14, 0x0a, 0x10, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f,
-	0x49, 0x4e, 0x56, 0x49, 0x54, 0x45, 0x5f, 0x41, 0x49, 0x10, 0x07, 0x42, 0x33, 0x5a, 0x31, 0x67,
-	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x68, 0x65, 0x72, 0x6f, 0x69, 0x63,
-	0x6c, 0x61, 0x62, 0x73, 0x2f, 0x6e, 0x61, 0x6b, 0x61, 0x6d, 0x61, 0x2d, 0x70, 0x72, 0x6f, 0x6a,
-	0x65, 0x63, 0x74, 0x2d, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x2f, 0x61, 0x70, 0x69,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x39, 0x0a, 0x0b, 0x53,
+	0x63, 0x6f, 0x72, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x64, 0x0a, 0x06, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x12, 0x1f, 0x0a, 0x05, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0e, 0x32,
+	0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x61, 0x72, 0x6b, 0x52, 0x05, 0x62, 0x6f, 0x61, 0x72,
+	0x64, 0x12, 0x1d, 0x0a, 0x04, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x61, 0x72, 0x6b, 0x52, 0x04, 0x6d, 0x61, 0x72, 0x6b,
+	0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x22, 0xcd, 0x02, 0x0a,
+	0x04, 0x44, 0x6f, 0x6e, 0x65, 0x12, 0x1f, 0x0a, 0x05, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0e, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x61, 0x72, 0x6b, 0x52,
+	0x05, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x12, 0x21, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x6e, 0x65, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x61, 0x72,
+	0x6b, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x29, 0x0a, 0x10, 0x77, 0x69, 0x6e,
+	0x6e, 0x65, 0x72, 0x5f, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x05, 0x52, 0x0f, 0x77, 0x69, 0x6e, 0x6e, 0x65, 0x72, 0x50, 0x6f, 0x73, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x67, 0x61, 0x6d,
+	0x65, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x6e,
+	0x65, 0x78, 0x74, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x2d, 0x0a, 0x06,
+	0x73, 0x63, 0x6f, 0x72, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x44, 0x6f, 0x6e, 0x65, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x06, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x73,
+	0x65, 0x72, 0x69, 0x65, 0x73, 0x5f, 0x64, 0x6f, 0x6e, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0a, 0x73, 0x65, 0x72, 0x69, 0x65, 0x73, 0x44, 0x6f, 0x6e, 0x65, 0x12, 0x23, 0x0a, 0x0d,
+	0x73, 0x65, 0x72, 0x69, 0x65, 0x73, 0x5f, 0x77, 0x69, 0x6e, 0x6e, 0x65, 0x72, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0c, 0x73, 0x65, 0x72, 0x69, 0x65, 0x73, 0x57, 0x69, 0x6e, 0x6e, 0x65,
+	0x72, 0x1a, 0x39, 0x0a, 0x0b, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x22, 0x0a, 0x04,
+	0x4d, 0x6f, 0x76, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x22, 0x63, 0x0a, 0x08, 0x52, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x29, 0x0a, 0x06,
+	0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x52, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x52,
+	0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x1f, 0x0a, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x08, 0x70, 0x6f, 0x73,
+	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x70, 0x6f, 0x73,
+	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x39, 0x0a, 0x13, 0x52, 0x70, 0x63, 0x46, 0x69, 0x6e, 0x64,
+	0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x66, 0x61, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x66, 0x61, 0x73, 0x74,
+	0x12, 0x0e, 0x0a, 0x02, 0x61, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x61, 0x69,
+	0x22, 0x33, 0x0a, 0x14, 0x52, 0x70, 0x63, 0x46, 0x69, 0x6e, 0x64, 0x4d, 0x61, 0x74, 0x63, 0x68,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x74, 0x63,
+	0x68, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x6d, 0x61, 0x74,
+	0x63, 0x68, 0x49, 0x64, 0x73, 0x2a, 0x34, 0x0a, 0x04, 0x4d, 0x61, 0x72, 0x6b, 0x12, 0x14, 0x0a,
+	0x10, 0x4d, 0x41, 0x52, 0x4b, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45,
+	0x44, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x4d, 0x41, 0x52, 0x4b, 0x5f, 0x58, 0x10, 0x01, 0x12,
+	0x0a, 0x0a, 0x06, 0x4d, 0x41, 0x52, 0x4b, 0x5f, 0x4f, 0x10, 0x02, 0x2a, 0xac, 0x01, 0x0a, 0x06,
+	0x4f, 0x70, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x0a, 0x12, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x10,
+	0x0a, 0x0c, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54, 0x10, 0x01,
+	0x12, 0x11, 0x0a, 0x0d, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x50, 0x44, 0x41, 0x54,
+	0x45, 0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x44, 0x4f,
+	0x4e, 0x45, 0x10, 0x03, 0x12, 0x0f, 0x0a, 0x0b, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x4d,
+	0x4f, 0x56, 0x45, 0x10, 0x04, 0x12, 0x13, 0x0a, 0x0f, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f,
+	0x52, 0x45, 0x4a, 0x45, 0x43, 0x54, 0x45, 0x44, 0x10, 0x05, 0x12, 0x18, 0x0a, 0x14, 0x4f, 0x50,
+	0x43, 0x4f, 0x44, 0x45, 0x5f, 0x4f, 0x50, 0x50, 0x4f, 0x4e, 0x45, 0x4e, 0x54, 0x5f, 0x4c, 0x45,
+	0x46, 0x54, 0x10, 0x06, 0x12, 0x14, 0x0a, 0x10, 0x4f, 0x50, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x49,
+	0x4e, 0x56, 0x49, 0x54, 0x45, 0x5f, 0x41, 0x49, 0x10, 0x07, 0x2a, 0xe4, 0x01, 0x0a, 0x0c, 0x52,
+	0x65, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x19, 0x52,
+	0x45, 0x4a, 0x45, 0x43, 0x54, 0x5f, 0x52, 0x45, 0x41, 0x53, 0x4f, 0x4e, 0x5f, 0x55, 0x4e, 0x53,
+	0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1b, 0x0a, 0x17, 0x52, 0x45,
+	0x4a, 0x45, 0x43, 0x54, 0x5f, 0x52, 0x45, 0x41, 0x53, 0x4f, 0x4e, 0x5f, 0x4d, 0x41, 0x4c, 0x46,
+	0x4f, 0x52, 0x4d, 0x45, 0x44, 0x10, 0x01, 0x12, 0x1f, 0x0a, 0x1b, 0x52, 0x45, 0x4a, 0x45, 0x43,
+	0x54, 0x5f, 0x52, 0x45, 0x41, 0x53, 0x4f, 0x4e, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x59, 0x4f, 0x55,
+	0x52, 0x5f, 0x54, 0x55, 0x52, 0x4e, 0x10, 0x02, 0x12, 0x1e, 0x0a, 0x1a, 0x52, 0x45, 0x4a, 0x45,
+	0x43, 0x54, 0x5f, 0x52, 0x45, 0x41, 0x53, 0x4f, 0x4e, 0x5f, 0x4f, 0x55, 0x54, 0x5f, 0x4f, 0x46,
+	0x5f, 0x52, 0x41, 0x4e, 0x47, 0x45, 0x10, 0x03, 0x12, 0x1e, 0x0a, 0x1a, 0x52, 0x45, 0x4a, 0x45,
+	0x43, 0x54, 0x5f, 0x52, 0x45, 0x41, 0x53, 0x4f, 0x4e, 0x5f, 0x52, 0x41, 0x54, 0x45, 0x5f, 0x4c,
+	0x49, 0x4d, 0x49, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12, 0x1a, 0x0a, 0x16, 0x52, 0x45, 0x4a, 0x45,
+	0x43, 0x54, 0x5f, 0x52, 0x45, 0x41, 0x53, 0x4f, 0x4e, 0x5f, 0x4f, 0x43, 0x43, 0x55, 0x50, 0x49,
+	0x45, 0x44, 0x10, 0x05, 0x12, 0x1b, 0x0a, 0x17, 0x52, 0x45, 0x4a, 0x45, 0x43, 0x54, 0x5f, 0x52,
+	0x45, 0x41, 0x53, 0x4f, 0x4e, 0x5f, 0x47, 0x41, 0x4d, 0x45, 0x5f, 0x4f, 0x56, 0x45, 0x52, 0x10,
+	0x06, 0x42, 0x33, 0x5a, 0x31, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x68, 0x65, 0x72, 0x6f, 0x69, 0x63, 0x6c, 0x61, 0x62, 0x73, 0x2f, 0x6e, 0x61, 0x6b, 0x61, 0x6d,
+	0x61, 0x2d, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x2d, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61,
+	0x74, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -614,33 +836,40 @@ func file_xoxoapi_proto_rawDescGZIP() []byte {
 	return file_xoxoapi_proto_rawDescData
 }
 
-var file_xoxoapi_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_xoxoapi_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_xoxoapi_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_xoxoapi_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_xoxoapi_proto_goTypes = []interface{}{
 	(Mark)(0),                    // 0: api.Mark
 	(OpCode)(0),                  // 1: api.OpCode
-	(*Start)(nil),                // 2: api.Start
-	(*Update)(nil),               // 3: api.Update
-	(*Done)(nil),                 // 4: api.Done
-	(*Move)(nil),                 // 5: api.Move
-	(*RpcFindMatchRequest)(nil),  // 6: api.RpcFindMatchRequest
-	(*RpcFindMatchResponse)(nil), // 7: api.RpcFindMatchResponse
-	nil,                          // 8: api.Start.MarksEntry
+	(RejectReason)(0),            // 2: api.RejectReason
+	(*Start)(nil),                // 3: api.Start
+	(*Update)(nil),               // 4: api.Update
+	(*Done)(nil),                 // 5: api.Done
+	(*Move)(nil),                 // 6: api.Move
+	(*Rejected)(nil),             // 7: api.Rejected
+	(*RpcFindMatchRequest)(nil),  // 8: api.RpcFindMatchRequest
+	(*RpcFindMatchResponse)(nil), // 9: api.RpcFindMatchResponse
+	nil,                          // 10: api.Start.MarksEntry
+	nil,                          // 11: api.Start.ScoresEntry
+	nil,                          // 12: api.Done.ScoresEntry
 }
 var file_xoxoapi_proto_depIdxs = []int32{
-	0, // 0: api.Start.board:type_name -> api.Mark
-	8, // 1: api.Start.marks:type_name -> api.Start.MarksEntry
-	0, // 2: api.Start.mark:type_name -> api.Mark
-	0, // 3: api.Update.board:type_name -> api.Mark
-	0, // 4: api.Update.mark:type_name -> api.Mark
-	0, // 5: api.Done.board:type_name -> api.Mark
-	0, // 6: api.Done.winner:type_name -> api.Mark
-	0, // 7: api.Start.MarksEntry.value:type_name -> api.Mark
-	8, // [8:8] is the sub-list for method output_type
-	8, // [8:8] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	0,  // 0: api.Start.board:type_name -> api.Mark
+	10, // 1: api.Start.marks:type_name -> api.Start.MarksEntry
+	0,  // 2: api.Start.mark:type_name -> api.Mark
+	11, // 3: api.Start.scores:type_name -> api.Start.ScoresEntry
+	0,  // 4: api.Update.board:type_name -> api.Mark
+	0,  // 5: api.Update.mark:type_name -> api.Mark
+	0,  // 6: api.Done.board:type_name -> api.Mark
+	0,  // 7: api.Done.winner:type_name -> api.Mark
+	12, // 8: api.Done.scores:type_name -> api.Done.ScoresEntry
+	2,  // 9: api.Rejected.reason:type_name -> api.RejectReason
+	0,  // 10: api.Start.MarksEntry.value:type_name -> api.Mark
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_xoxoapi_proto_init() }
@@ -698,7 +927,7 @@ func file_xoxoapi_proto_init() {
 			}
 		}
 		file_xoxoapi_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RpcFindMatchRequest); i {
+			switch v := v.(*Rejected); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -710,6 +939,18 @@ func file_xoxoapi_proto_init() {
 			}
 		}
 		file_xoxoapi_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RpcFindMatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_xoxoapi_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RpcFindMatchResponse); i {
 			case 0:
 				return &v.state
@@ -722,13 +963,14 @@ func file_xoxoapi_proto_init() {
 			}
 		}
 	}
+	file_xoxoapi_proto_msgTypes[4].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_xoxoapi_proto_rawDesc,
-			NumEnums:      2,
-			NumMessages:   7,
+			NumEnums:      3,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/rtapi"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// Session vars the client sends on authentication to describe itself.
+const (
+	varClientVersion = "client_version"
+	varPlatform      = "platform"
+)
+
+// Runtime env keys configuring the client compatibility gate. Each key applies to all
+// platforms, or to one platform when suffixed with it, e.g. "client_min_version_ios".
+const (
+	envClientMinVersion = "client_min_version"
+	envClientUpgradeURL = "client_upgrade_url"
+)
+
+// gatedRtMessages are the realtime messages rejected for unsupported clients.
+var gatedRtMessages = []string{"ChannelJoin", "MatchCreate", "MatchJoin", "MatchmakerAdd", "PartyCreate", "PartyJoin"}
+
+// clientGate rejects clients older than the minimum version of their platform.
+type clientGate struct {
+	// minVersions and upgradeURLs are keyed by platform, the empty platform holds the
+	// value used for platforms without their own.
+	minVersions map[string]string
+	upgradeURLs map[string]string
+}
+
+// newClientGateFromEnv reads the minimum client versions and upgrade URLs from the
+// runtime env. Without any minimum version configured the gate lets every client in.
+func newClientGateFromEnv(env map[string]string) (*clientGate, error) {
+	gate := &clientGate{
+		minVersions: make(map[string]string),
+		upgradeURLs: make(map[string]string),
+	}
+	for key, value := range env {
+		switch {
+		case key == envClientMinVersion:
+			gate.minVersions[""] = value
+		case strings.HasPrefix(key, envClientMinVersion+"_"):
+			gate.minVersions[strings.ToLower(strings.TrimPrefix(key, envClientMinVersion+"_"))] = value
+		case key == envClientUpgradeURL:
+			gate.upgradeURLs[""] = value
+		case strings.HasPrefix(key, envClientUpgradeURL+"_"):
+			gate.upgradeURLs[strings.ToLower(strings.TrimPrefix(key, envClientUpgradeURL+"_"))] = value
+		default:
+			continue
+		}
+		if strings.HasPrefix(key, envClientMinVersion) {
+			if _, err := parseVersion(value); err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", key, err)
+			}
+		}
+	}
+	return gate, nil
+}
+
+// check returns a FAILED_PRECONDITION error if the client described by the session
+// vars is older than the minimum version of its platform or doesn't report a version.
+func (g *clientGate) check(vars map[string]string) error {
+	platform := strings.ToLower(vars[varPlatform])
+	minVersion, found := g.minVersions[platform]
+	if !found {
+		if minVersion, found = g.minVersions[""]; !found {
+			return nil
+		}
+	}
+	upgradeURL, found := g.upgradeURLs[platform]
+	if !found {
+		upgradeURL = g.upgradeURLs[""]
+	}
+
+	clientVersion := vars[varClientVersion]
+	if clientVersion != "" {
+		supported, err := versionAtLeast(clientVersion, minVersion)
+		if err == nil && supported {
+			return nil
+		}
+	}
+
+	message := fmt.Sprintf("client version %q is not supported, upgrade to %s or newer", clientVersion, minVersion)
+	if upgradeURL != "" {
+		message += ": " + upgradeURL
+	}
+	return runtime.NewError(message, 9) // FAILED_PRECONDITION
+}
+
+// register installs the gate in front of every authentication method and session
+// refresh, and adds it to the hooks of the realtime messages used to start playing.
+func (g *clientGate) register(initializer runtime.Initializer, rtHooks *beforeRtHooks) error {
+	if err := initializer.RegisterBeforeAuthenticateApple(func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *api.AuthenticateAppleRequest) (*api.AuthenticateAppleRequest, error) {
+		return in, g.check(in.GetAccount().GetVars())
+	}); err != nil {
+		return err
+	}
+	if err := initializer.RegisterBeforeAuthenticateCustom(func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *api.AuthenticateCustomRequest) (*api.AuthenticateCustomRequest, error) {
+		return in, g.check(in.GetAccount().GetVars())
+	}); err != nil {
+		return err
+	}
+	if err := initializer.RegisterBeforeAuthenticateDevice(func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *api.AuthenticateDeviceRequest) (*api.AuthenticateDeviceRequest, error) {
+		return in, g.check(in.GetAccount().GetVars())
+	}); err != nil {
+		return err
+	}
+	if err := initializer.RegisterBeforeAuthenticateEmail(func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *api.AuthenticateEmailRequest) (*api.AuthenticateEmailRequest, error) {
+		return in, g.check(in.GetAccount().GetVars())
+	}); err != nil {
+		return err
+	}
+	if err := initializer.RegisterBeforeAuthenticateFacebook(func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *api.AuthenticateFacebookRequest) (*api.AuthenticateFacebookRequest, error) {
+		return in, g.check(in.GetAccount().GetVars())
+	}); err != nil {
+		return err
+	}
+	if err := initializer.RegisterBeforeAuthenticateFacebookInstantGame(func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *api.AuthenticateFacebookInstantGameRequest) (*api.AuthenticateFacebookInstantGameRequest, error) {
+		return in, g.check(in.GetAccount().GetVars())
+	}); err != nil {
+		return err
+	}
+	if err := initializer.RegisterBeforeAuthenticateGameCenter(func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *api.AuthenticateGameCenterRequest) (*api.AuthenticateGameCenterRequest, error) {
+		return in, g.check(in.GetAccount().GetVars())
+	}); err != nil {
+		return err
+	}
+	if err := initializer.RegisterBeforeAuthenticateGoogle(func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *api.AuthenticateGoogleRequest) (*api.AuthenticateGoogleRequest, error) {
+		return in, g.check(in.GetAccount().GetVars())
+	}); err != nil {
+		return err
+	}
+	if err := initializer.RegisterBeforeAuthenticateSteam(func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *api.AuthenticateSteamRequest) (*api.AuthenticateSteamRequest, error) {
+		return in, g.check(in.GetAccount().GetVars())
+	}); err != nil {
+		return err
+	}
+	if err := initializer.RegisterBeforeSessionRefresh(func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *api.SessionRefreshRequest) (*api.SessionRefreshRequest, error) {
+		// Refreshed sessions keep the vars checked on authentication unless new ones are sent.
+		if len(in.GetVars()) == 0 {
+			return in, nil
+		}
+		return in, g.check(in.GetVars())
+	}); err != nil {
+		return err
+	}
+	for _, id := range gatedRtMessages {
+		rtHooks.add(id, func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *rtapi.Envelope) (*rtapi.Envelope, error) {
+			vars, _ := ctx.Value(runtime.RUNTIME_CTX_VARS).(map[string]string)
+			return in, g.check(vars)
+		})
+	}
+	return nil
+}
+
+// parseVersion splits a dotted version such as "1.10.2" into its numeric parts.
+func parseVersion(version string) ([]int, error) {
+	fields := strings.Split(version, ".")
+	parts := make([]int, len(fields))
+	for i, field := range fields {
+		part, err := strconv.Atoi(field)
+		if err != nil || part < 0 {
+			return nil, fmt.Errorf("malformed version %q", version)
+		}
+		parts[i] = part
+	}
+	return parts, nil
+}
+
+// versionAtLeast reports whether version is the same as or newer than minVersion.
+// Missing trailing parts count as zero, so "1.2" equals "1.2.0".
+func versionAtLeast(version, minVersion string) (bool, error) {
+	parts, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+	minParts, err := parseVersion(minVersion)
+	if err != nil {
+		return false, err
+	}
+	for i := 0; i < len(parts) || i < len(minParts); i++ {
+		var part, minPart int
+		if i < len(parts) {
+			part = parts[i]
+		}
+		if i < len(minParts) {
+			minPart = minParts[i]
+		}
+		if part != minPart {
+			return part > minPart, nil
+		}
+	}
+	return true, nil
+}
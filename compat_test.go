@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionAtLeast(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		version, minVersion string
+		expected            bool
+	}{
+		{"1.2.0", "1.2.0", true},
+		{"1.10.0", "1.9.9", true},
+		{"1.2", "1.2.0", true},
+		{"1.2.0", "1.2.1", false},
+		{"0.9", "1", false},
+		{"2", "1.99.99", true},
+	}
+	for _, tt := range tests {
+		supported, err := versionAtLeast(tt.version, tt.minVersion)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, supported, "%s >= %s", tt.version, tt.minVersion)
+	}
+
+	_, err := versionAtLeast("1.x", "1.0")
+	assert.Error(t, err)
+}
+
+func TestClientGate(t *testing.T) {
+	t.Parallel()
+	gate, err := newClientGateFromEnv(map[string]string{
+		"client_min_version":         "1.0.0",
+		"client_min_version_ios":     "1.2.0",
+		"client_upgrade_url":         "https://example.com/download",
+		"client_upgrade_url_ios":     "https://apps.apple.com/app/xoxo",
+		"version_checker_rate_limit": "1",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, gate.check(map[string]string{"platform": "ios", "client_version": "1.2.0"}))
+	assert.NoError(t, gate.check(map[string]string{"platform": "android", "client_version": "1.0.0"}))
+	assert.NoError(t, gate.check(map[string]string{"client_version": "1.1.0"}))
+
+	err = gate.check(map[string]string{"platform": "ios", "client_version": "1.1.0"})
+	if assert.Error(t, err) {
+		assert.Equal(t, 9, err.(*runtime.Error).Code)
+		assert.Contains(t, err.Error(), "https://apps.apple.com/app/xoxo")
+	}
+	err = gate.check(map[string]string{"platform": "android", "client_version": "0.9.0"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "https://example.com/download")
+	}
+	assert.Error(t, gate.check(nil), "Expected clients without a version to be rejected")
+
+	gate, err = newClientGateFromEnv(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, gate.check(nil), "Expected an unconfigured gate to let everyone in")
+
+	_, err = newClientGateFromEnv(map[string]string{"client_min_version_pc": "latest"})
+	assert.Error(t, err)
+}
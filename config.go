@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// envModuleConfigFile names a JSON file holding the module config. Its keys are the same
+// as the runtime env keys, values set in the runtime env take precedence over the file.
+const envModuleConfigFile = "module_config_file"
+
+// envModuleConfigPoll is the interval in seconds at which the config file is checked for
+// changes and reloaded, 0 disables polling.
+const envModuleConfigPoll = "module_config_poll_interval"
+
+// envFeaturePrefix prefixes the runtime env keys of feature toggles, e.g. "feature_ai=false".
+const envFeaturePrefix = "feature_"
+
+// featureAI lets players play against the AI, in matches created for it and by inviting
+// it to replace a player who left. It's on unless turned off.
+const featureAI = "ai"
+
+// uuidPattern matches the canonical textual form of a UUID.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ModuleConfig holds the settings which can change between environments without
+// rebuilding the plugin. The JSON keys double as runtime env keys.
+type ModuleConfig struct {
+	// ManifestRoot is the directory manifests are read from, the working directory when empty.
+	ManifestRoot string `json:"manifest_root"`
+	// DefaultType and DefaultVersion are checked when the client doesn't send them.
+	DefaultType    string `json:"version_checker_default_type"`
+	DefaultVersion string `json:"version_checker_default_version"`
+	// CollectionName is the storage collection version checks are saved to.
+	CollectionName string `json:"version_checker_collection"`
+	// SystemUserID owns the storage objects saved by the version checker.
+	SystemUserID string `json:"version_checker_system_user_id"`
+	// RateLimit and RateBurst configure the version checker rate limit per caller.
+	RateLimit float64 `json:"version_checker_rate_limit"`
+	RateBurst int     `json:"version_checker_rate_burst"`
+	// TournamentsManifest is the type/version of the manifest defining the recurring
+	// tournaments, e.g. "tournaments/1.0.0". Tournaments are off when empty.
+	TournamentsManifest string `json:"tournaments_manifest"`
+	// Features toggles behavior on and off by name.
+	Features map[string]bool `json:"features"`
+
+	// tournaments are the tournaments of the tournaments manifest, loaded with the config.
+	tournaments []*TournamentConfig
+}
+
+// defaultModuleConfig returns the config used for settings not found in the runtime env.
+func defaultModuleConfig() *ModuleConfig {
+	return &ModuleConfig{
+		ManifestRoot:   "",
+		DefaultType:    "core",
+		DefaultVersion: "1.0.0",
+		CollectionName: "ZeptoLabVersionChecker",
+		SystemUserID:   "00000000-0000-0000-0000-000000000000",
+		RateLimit:      1.0,
+		RateBurst:      5,
+		Features:       map[string]bool{featureAI: true},
+	}
+}
+
+// moduleConfig is the config in use, set once the module is initialized. A config is
+// never modified once stored, reloading stores a new one.
+var moduleConfig atomic.Pointer[ModuleConfig]
+
+// currentConfig returns the config in use, or the default config before the module
+// is initialized.
+func currentConfig() *ModuleConfig {
+	if config := moduleConfig.Load(); config != nil {
+		return config
+	}
+	return defaultModuleConfig()
+}
+
+// featureEnabled reports whether the feature toggle of the given name is on.
+func featureEnabled(name string) bool {
+	return currentConfig().Features[name]
+}
+
+// loadModuleConfig builds the module config from the defaults, the optional config
+// file and the runtime env, in increasing order of precedence, and validates it.
+func loadModuleConfig(env map[string]string) (*ModuleConfig, error) {
+	config := defaultModuleConfig()
+
+	if fileName := env[envModuleConfigFile]; fileName != "" {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", envModuleConfigFile, err)
+		}
+		if err := json.Unmarshal(content, config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", fileName, err)
+		}
+	}
+
+	for key, value := range env {
+		var err error
+		switch key {
+		case "manifest_root":
+			config.ManifestRoot = value
+		case "version_checker_default_type":
+			config.DefaultType = value
+		case "version_checker_default_version":
+			config.DefaultVersion = value
+		case "version_checker_collection":
+			config.CollectionName = value
+		case "version_checker_system_user_id":
+			config.SystemUserID = value
+		case "version_checker_rate_limit":
+			config.RateLimit, err = strconv.ParseFloat(value, 64)
+		case "version_checker_rate_burst":
+			config.RateBurst, err = strconv.Atoi(value)
+		case "tournaments_manifest":
+			config.TournamentsManifest = value
+		default:
+			if strings.HasPrefix(key, envFeaturePrefix) {
+				if config.Features == nil {
+					config.Features = make(map[string]bool)
+				}
+				config.Features[strings.TrimPrefix(key, envFeaturePrefix)], err = strconv.ParseBool(value)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", key, value)
+		}
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	var err error
+	if config.tournaments, err = loadTournaments(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *ModuleConfig) validate() error {
+	if c.ManifestRoot != "" {
+		if info, err := os.Stat(c.ManifestRoot); err != nil || !info.IsDir() {
+			return fmt.Errorf("invalid manifest_root: %q is not a directory", c.ManifestRoot)
+		}
+	}
+	if err := (&Payload{Type: c.DefaultType, Version: c.DefaultVersion}).validate(); err != nil || c.DefaultType == "" || c.DefaultVersion == "" {
+		return fmt.Errorf("invalid default manifest: %q/%q", c.DefaultType, c.DefaultVersion)
+	}
+	if strings.TrimSpace(c.CollectionName) == "" {
+		return fmt.Errorf("invalid version_checker_collection: %q", c.CollectionName)
+	}
+	if !uuidPattern.MatchString(c.SystemUserID) {
+		return fmt.Errorf("invalid version_checker_system_user_id: %q", c.SystemUserID)
+	}
+	if c.RateLimit <= 0 {
+		return fmt.Errorf("invalid version_checker_rate_limit: %v", c.RateLimit)
+	}
+	if c.RateBurst <= 0 {
+		return fmt.Errorf("invalid version_checker_rate_burst: %v", c.RateBurst)
+	}
+	if c.TournamentsManifest != "" {
+		if _, err := c.tournamentsPayload(); err != nil {
+			return fmt.Errorf("invalid tournaments_manifest: %q", c.TournamentsManifest)
+		}
+	}
+	return nil
+}
+
+// tournamentsPayload splits the tournaments manifest setting into the manifest type
+// and version.
+func (c *ModuleConfig) tournamentsPayload() (*Payload, error) {
+	manifestType, version, found := strings.Cut(c.TournamentsManifest, "/")
+	p := &Payload{Type: manifestType, Version: version}
+	if !found || manifestType == "" || version == "" {
+		return nil, fmt.Errorf("expected type/version, got %q", c.TournamentsManifest)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// log writes the config in use to the startup log.
+func (c *ModuleConfig) log(logger runtime.Logger) {
+	logger.Info("Module config: manifest_root=%q default=%s/%s collection=%q system_user_id=%s rate_limit=%v rate_burst=%d tournaments_manifest=%q features=%v",
+		c.ManifestRoot, c.DefaultType, c.DefaultVersion, c.CollectionName, c.SystemUserID, c.RateLimit, c.RateBurst, c.TournamentsManifest, c.Features)
+}
+
+// configReloader reloads the module config from the runtime env captured at start and
+// the config file, which is the part that can change while Nakama is running.
+type configReloader struct {
+	sync.Mutex
+	env         map[string]string
+	limiter     *rateLimiter
+	nk          runtime.NakamaModule
+	fileModTime time.Time
+}
+
+func newConfigReloader(env map[string]string, limiter *rateLimiter, nk runtime.NakamaModule) *configReloader {
+	return &configReloader{env: env, limiter: limiter, nk: nk}
+}
+
+// reload loads and validates the config, creates the tournaments of its tournaments
+// manifest which don't exist yet and swaps it in. An invalid config leaves the config
+// in use untouched.
+func (r *configReloader) reload(ctx context.Context, logger runtime.Logger) (*ModuleConfig, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if fileName := r.env[envModuleConfigFile]; fileName != "" {
+		if info, err := os.Stat(fileName); err == nil {
+			r.fileModTime = info.ModTime()
+		}
+	}
+	config, err := loadModuleConfig(r.env)
+	if err != nil {
+		return nil, err
+	}
+	if err := createTournaments(ctx, r.nk, config.tournaments); err != nil {
+		return nil, err
+	}
+	moduleConfig.Store(config)
+	if r.limiter != nil {
+		r.limiter.setLimits(config.RateLimit, config.RateBurst)
+	}
+	config.log(logger)
+	return config, nil
+}
+
+// poll reloads the config whenever the config file changes, checking it every interval
+// until the context is done.
+func (r *configReloader) poll(ctx context.Context, logger runtime.Logger, interval time.Duration) {
+	fileName := r.env[envModuleConfigFile]
+	if fileName == "" || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(fileName)
+			if err != nil {
+				logger.Warn("Unable to check module config file: %v", err)
+				continue
+			}
+			r.Lock()
+			changed := !info.ModTime().Equal(r.fileModTime)
+			r.Unlock()
+			if !changed {
+				continue
+			}
+			if _, err := r.reload(ctx, logger); err != nil {
+				logger.Error("Unable to reload module config, keeping the current one: %v", err)
+			}
+		}
+	}
+}
+
+// pollInterval returns the config file poll interval set in the runtime env.
+func (r *configReloader) pollInterval() (time.Duration, error) {
+	value, found := r.env[envModuleConfigPoll]
+	if !found {
+		return 0, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", envModuleConfigPoll, value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// newReloadConfigRpc returns the ReloadConfig RPC function, which reloads the module
+// config on demand and returns the config now in use.
+func newReloadConfigRpc(reloader *configReloader) rpcFunc {
+	return newRpc("ReloadConfig", func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, _ *struct{}) (*ModuleConfig, error) {
+		config, err := reloader.reload(ctx, logger)
+		if err != nil {
+			return nil, runtime.NewError(err.Error(), 9) // FAILED_PRECONDITION
+		}
+		return config, nil
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tournamentCreateNakamaModule keeps the IDs of the tournaments created.
+type tournamentCreateNakamaModule struct {
+	testNakamaModule
+	created []string
+}
+
+func (t *tournamentCreateNakamaModule) TournamentCreate(ctx context.Context, id string, authoritative bool, sortOrder string, operator string, resetSchedule string, metadata map[string]interface{}, title string, description string, category int, startTime int, endTime int, duration int, maxSize int, maxNumScore int, joinRequired bool) error {
+	t.created = append(t.created, id)
+	return nil
+}
+
+func TestLoadModuleConfig(t *testing.T) {
+	t.Parallel()
+	config, err := loadModuleConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultModuleConfig(), config)
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "module.json")
+	err = os.WriteFile(configFile, []byte(`{"version_checker_default_type": "levels", "version_checker_rate_burst": 20}`), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	config, err = loadModuleConfig(map[string]string{
+		"module_config_file":              configFile,
+		"manifest_root":                   dir,
+		"version_checker_default_version": "2.0.0",
+		"version_checker_rate_burst":      "10",
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, dir, config.ManifestRoot)
+		assert.Equal(t, "levels", config.DefaultType, "Expected the value of the config file")
+		assert.Equal(t, "2.0.0", config.DefaultVersion, "Expected the value of the runtime env")
+		assert.Equal(t, 10, config.RateBurst, "Expected the runtime env to take precedence over the config file")
+		assert.Equal(t, "ZeptoLabVersionChecker", config.CollectionName, "Expected the default value")
+	}
+
+	for key, value := range map[string]string{
+		"module_config_file":              filepath.Join(dir, "missing.json"),
+		"manifest_root":                   filepath.Join(dir, "missing"),
+		"version_checker_default_type":    "../etc",
+		"version_checker_default_version": "",
+		"version_checker_collection":      " ",
+		"version_checker_system_user_id":  "system",
+		"version_checker_rate_limit":      "0",
+		"version_checker_rate_burst":      "many",
+	} {
+		_, err := loadModuleConfig(map[string]string{key: value})
+		assert.Error(t, err, "Expected %s=%q to be rejected", key, value)
+	}
+}
+
+func TestConfigReloader(t *testing.T) {
+	// Reloading swaps the config used by the whole module, so this test doesn't run in parallel.
+	defer moduleConfig.Store(nil)
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "module.json")
+	writeConfig := func(content string) {
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	writeConfig(`{"version_checker_default_version": "1.1.0", "features": {"ai": true}}`)
+
+	limiter := newRateLimiter(0, 0)
+	nk := &tournamentCreateNakamaModule{}
+	reloader := newConfigReloader(map[string]string{"module_config_file": configFile, "version_checker_rate_burst": "7"}, limiter, nk)
+	_, err := reloader.reload(context.Background(), &testLogger{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.0", currentConfig().DefaultVersion)
+	assert.True(t, featureEnabled("ai"))
+	assert.Equal(t, 7.0, limiter.burst)
+
+	writeConfig(`{"version_checker_default_version": "1.2.0", "features": {"ai": false}}`)
+	config, err := reloader.reload(context.Background(), &testLogger{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.0", config.DefaultVersion)
+	assert.Same(t, config, currentConfig())
+	assert.False(t, featureEnabled("ai"))
+	_, err = FindMatch(context.Background(), &testLogger{}, nil, &testNakamaModule{}, `{"ai": true}`)
+	assert.Equal(t, 9, rpcErrorCode(err), "Expected the AI to be turned off")
+
+	writeConfig(`{"version_checker_system_user_id": "nobody"}`)
+	_, err = reloader.reload(context.Background(), &testLogger{})
+	assert.Error(t, err)
+	assert.Same(t, config, currentConfig(), "Expected an invalid config to keep the current one")
+
+	if err := os.MkdirAll(filepath.Join(dir, "tournaments"), 0755); err != nil {
+		t.Fatalf("Failed to create test dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tournaments", "1.0.0.json"), []byte(`{"tournaments": [{"id": "weekly", "reset_schedule": "0 0 * * 1", "duration": 604800}]}`), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	writeConfig(fmt.Sprintf(`{"manifest_root": %q, "tournaments_manifest": "tournaments/1.0.0"}`, dir))
+	_, err = reloader.reload(context.Background(), &testLogger{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"weekly"}, nk.created, "Expected the tournaments of a reloaded manifest to be created")
+}
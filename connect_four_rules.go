@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+const (
+	connectFourRows      = 6
+	connectFourColumns   = 7
+	connectFourWinLength = 4
+)
+
+// connectFourRules are the rules of Connect Four: players drop their mark in one of the
+// columns of a 6 by 7 board, where it falls to the lowest free cell, and the first to
+// get four marks in a row wins. A move is the column played, counted from the left.
+type connectFourRules struct {
+	lineBoard
+}
+
+func newConnectFourRules() *connectFourRules {
+	return &connectFourRules{lineBoard: lineBoard{rows: connectFourRows, columns: connectFourColumns, winLength: connectFourWinLength}}
+}
+
+func (r *connectFourRules) newBoard() []api.Mark {
+	return make([]api.Mark, r.cells())
+}
+
+func (r *connectFourRules) legalMoves(board []api.Mark) []int32 {
+	var moves []int32
+	for column := 0; column < r.columns; column++ {
+		// A column is free as long as its top cell is.
+		if board[column] == api.Mark_MARK_UNSPECIFIED {
+			moves = append(moves, int32(column))
+		}
+	}
+	return moves
+}
+
+func (r *connectFourRules) apply(board []api.Mark, move int32, mark api.Mark) (int32, error) {
+	if move < 0 || move >= int32(r.columns) {
+		return 0, errMoveOutOfRange
+	}
+	for row := r.rows - 1; row >= 0; row-- {
+		cell := int32(row*r.columns) + move
+		if board[cell] == api.Mark_MARK_UNSPECIFIED {
+			board[cell] = mark
+			return cell, nil
+		}
+	}
+	return 0, errCellOccupied
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+func TestConnectFourRulesApply(t *testing.T) {
+	t.Parallel()
+	rules := newConnectFourRules()
+	board := rules.newBoard()
+	assert.Len(t, board, 42)
+
+	for row := connectFourRows - 1; row >= 0; row-- {
+		cell, err := rules.apply(board, 3, api.Mark_MARK_X)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(row*connectFourColumns+3), cell, "Expected the mark to fall to the lowest free cell")
+	}
+	_, err := rules.apply(board, 3, api.Mark_MARK_O)
+	assert.Equal(t, errCellOccupied, err, "Expected a full column to be rejected")
+	_, err = rules.apply(board, connectFourColumns, api.Mark_MARK_O)
+	assert.Equal(t, errMoveOutOfRange, err)
+	assert.Equal(t, []int32{0, 1, 2, 4, 5, 6}, rules.legalMoves(board))
+}
+
+func TestNewGameRules(t *testing.T) {
+	t.Parallel()
+	rules, err := newGameRules(map[string]interface{}{"game": "connect4"})
+	assert.NoError(t, err)
+	assert.IsType(t, &connectFourRules{}, rules)
+	rules, err = newGameRules(nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &xoxoRules{}, rules)
+	_, err = newGameRules(map[string]interface{}{"game": "chess"})
+	assert.Error(t, err)
+}
+
+func TestMatchHandlerConnectFour(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, map[string]interface{}{"game": "connect4"})
+	assert.Equal(t, "connect4", m.matchState().label.Game)
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+
+	m.loop(m.move(x, 0), m.move(o, 1), m.move(x, 0), m.move(o, 1), m.move(x, 0), m.move(o, 1))
+	assert.Equal(t, api.Mark_MARK_X, m.matchState().board[35], "Expected the first mark at the bottom of the column")
+	m.loop(m.move(x, 0))
+
+	done := &api.Done{}
+	if assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_DONE)) {
+		assert.NoError(t, proto.Unmarshal(m.dispatcher.last(api.OpCode_OPCODE_DONE).data, done))
+		assert.Equal(t, api.Mark_MARK_X, done.Winner)
+		assert.Equal(t, []int32{14, 21, 28, 35}, done.WinnerPositions)
+	}
+}
+
+func TestAIMoveConnectFour(t *testing.T) {
+	t.Parallel()
+	random := newTestMatch(t, nil).matchState().random
+	rules := newConnectFourRules()
+	board := rules.newBoard()
+	for _, column := range []int32{2, 2, 2} {
+		rules.apply(board, column, api.Mark_MARK_X)
+	}
+	assert.Equal(t, int32(2), aiMove(random, rules, board, api.Mark_MARK_O), "Expected the AI to block the column")
+	assert.Equal(t, int32(3), aiMove(random, rules, rules.newBoard(), api.Mark_MARK_O), "Expected the AI to take the center column")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/heroiclabs/nakama-common/rtapi"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// beforeRtHook is a before hook of a realtime message.
+type beforeRtHook = func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *rtapi.Envelope) (*rtapi.Envelope, error)
+
+// beforeRtHooks collects the before hooks several features install on the same realtime
+// messages, as Nakama keeps a single hook per message.
+type beforeRtHooks struct {
+	ids   []string
+	hooks map[string][]beforeRtHook
+}
+
+func newBeforeRtHooks() *beforeRtHooks {
+	return &beforeRtHooks{hooks: make(map[string][]beforeRtHook)}
+}
+
+// add appends a hook for the message, hooks run in the order they were added.
+func (h *beforeRtHooks) add(id string, hook beforeRtHook) {
+	if _, ok := h.hooks[id]; !ok {
+		h.ids = append(h.ids, id)
+	}
+	h.hooks[id] = append(h.hooks[id], hook)
+}
+
+// register installs one hook per message which runs the hooks added for it in turn,
+// each getting the envelope returned by the previous one. The first error rejects the
+// message.
+func (h *beforeRtHooks) register(initializer runtime.Initializer) error {
+	for _, id := range h.ids {
+		hooks := h.hooks[id]
+		if err := initializer.RegisterBeforeRt(id, func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *rtapi.Envelope) (*rtapi.Envelope, error) {
+			for _, hook := range hooks {
+				var err error
+				if in, err = hook(ctx, logger, db, nk, in); err != nil || in == nil {
+					return in, err
+				}
+			}
+			return in, nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -27,6 +27,7 @@ var (
 	errMarshal        = runtime.NewError("cannot marshal type", 13)   // INTERNAL
 	errNoInputAllowed = runtime.NewError("no input allowed", 3)       // INVALID_ARGUMENT
 	errNoUserIdFound  = runtime.NewError("no user ID in context", 3)  // INVALID_ARGUMENT
+	errPermission     = runtime.NewError("permission denied", 7)      // PERMISSION_DENIED
 	errUnmarshal      = runtime.NewError("cannot unmarshal type", 13) // INTERNAL
 )
 
@@ -39,11 +40,92 @@ func InitModule(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runti
 
 	initStart := time.Now()
 
-	if err := initializer.RegisterRpc("VersionChecker", VersionChecker); err != nil {
+	env, _ := ctx.Value(runtime.RUNTIME_CTX_ENV).(map[string]string)
+	if err := createLeaderboards(ctx, nk); err != nil {
+		logger.Error("Unable to create leaderboards: %v", err)
+		return err
+	}
+
+	// Loading the config also creates the tournaments it defines.
+	limiter := newRateLimiter(0, 0)
+	reloader := newConfigReloader(env, limiter, nk)
+	if _, err := reloader.reload(ctx, logger); err != nil {
+		logger.Error("Unable to load module config: %v", err)
+		return err
+	}
+	pollInterval, err := reloader.pollInterval()
+	if err != nil {
+		logger.Error("Unable to load module config: %v", err)
+		return err
+	}
+	go reloader.poll(ctx, logger, pollInterval)
+
+	gate, err := newClientGateFromEnv(env)
+	if err != nil {
+		logger.Error("Unable to configure client compatibility gate: %v", err)
+		return err
+	}
+	rtHooks := newBeforeRtHooks()
+	if err := gate.register(initializer, rtHooks); err != nil {
+		logger.Error("Unable to register client compatibility gate: %v", err)
+		return err
+	}
+	rtHooks.add("MatchmakerAdd", addRatingProperty)
+	if err := rtHooks.register(initializer); err != nil {
+		logger.Error("Unable to register realtime hooks: %v", err)
+		return err
+	}
+
+	if err := initializer.RegisterRpc("VersionChecker", withMiddleware(VersionChecker, rateLimit(limiter))); err != nil {
+		logger.Error("Unable to register RPC: %v", err)
+		return err
+	}
+
+	if err := initializer.RegisterRpc("VersionAdoption", withMiddleware(VersionAdoption, requireAdmin)); err != nil {
+		logger.Error("Unable to register RPC: %v", err)
+		return err
+	}
+
+	if err := initializer.RegisterRpc("ReloadConfig", withMiddleware(newReloadConfigRpc(reloader), requireAdmin, noInput)); err != nil {
+		logger.Error("Unable to register RPC: %v", err)
+		return err
+	}
+
+	if err := initializer.RegisterRpc("find_match", withMiddleware(FindMatch, requireUser)); err != nil {
+		logger.Error("Unable to register RPC: %v", err)
+		return err
+	}
+
+	if err := initializer.RegisterRpc("get_replay", GetReplay); err != nil {
+		logger.Error("Unable to register RPC: %v", err)
+		return err
+	}
+
+	if err := initializer.RegisterRpc("get_rating", GetRating); err != nil {
+		logger.Error("Unable to register RPC: %v", err)
+		return err
+	}
+
+	if err := initializer.RegisterRpc("get_stats", GetStats); err != nil {
 		logger.Error("Unable to register RPC: %v", err)
 		return err
 	}
 
+	if err := initializer.RegisterMatch(xoxoModuleName, newMatchHandler); err != nil {
+		logger.Error("Unable to register match: %v", err)
+		return err
+	}
+
+	if err := initializer.RegisterMatchmakerMatched(MatchmakerMatched); err != nil {
+		logger.Error("Unable to register matchmaker matched: %v", err)
+		return err
+	}
+
+	if err := initializer.RegisterTournamentEnd(TournamentEnd); err != nil {
+		logger.Error("Unable to register tournament end: %v", err)
+		return err
+	}
+
 	logger.Info("Plugin loaded in '%d' msec.", time.Now().Sub(initStart).Milliseconds())
 	return nil
 }
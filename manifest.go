@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// variantFieldPattern restricts the platform, locale and channel fields to a single
+// file name segment, so they can't be used to escape the manifest directory.
+var variantFieldPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// manifestVariants returns the variant qualifiers to try for the payload, from the
+// most specific to the least specific. The last element is always the empty
+// qualifier which stands for the base manifest, e.g. for platform "android" and
+// locale "de" the result is ["android.de", "android", ""].
+func manifestVariants(p Payload) ([]string, error) {
+	var dims []string
+	for _, field := range []struct{ name, value string }{
+		{"platform", p.Platform},
+		{"locale", p.Locale},
+		{"channel", p.Channel},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if !variantFieldPattern.MatchString(field.value) {
+			return nil, fmt.Errorf("invalid %s: %q", field.name, field.value)
+		}
+		dims = append(dims, field.value)
+	}
+
+	variants := make([]string, 0, len(dims)+1)
+	for i := len(dims); i > 0; i-- {
+		variants = append(variants, strings.Join(dims[:i], "."))
+	}
+	return append(variants, ""), nil
+}
+
+// manifestPath builds the file path of a manifest variant, e.g. core/1.0.0.android.json.
+func manifestPath(manifestType, version, variant string) string {
+	name := version
+	if variant != "" {
+		name += "." + variant
+	}
+	return filepath.Join(manifestType, name+".json")
+}
+
+// resolveManifest walks the variant fallback chain below the manifest root and returns
+// the path of the first manifest found on disk together with the variant it represents.
+// If no variant exists the error of the base manifest lookup is returned.
+func resolveManifest(root string, p Payload) (string, string, error) {
+	variants, err := manifestVariants(p)
+	if err != nil {
+		return "", "", err
+	}
+
+	var statErr error
+	for _, variant := range variants {
+		filePath := filepath.Join(root, manifestPath(p.Type, p.Version, variant))
+		if _, statErr = os.Stat(filePath); statErr == nil {
+			return filePath, variant, nil
+		}
+	}
+	return "", "", fmt.Errorf("file not found: %w", statErr)
+}
+
+const (
+	// manifestExtendsKey names the parent manifest, e.g. "extends": "core/1.0.0".
+	manifestExtendsKey = "extends"
+	// manifestArraysKey maps dotted field paths to an array merge strategy, e.g.
+	// "mergeArrays": {"features.enabled": "append"}. Unlisted arrays are replaced.
+	manifestArraysKey = "mergeArrays"
+	// maxManifestDepth bounds the length of an inheritance chain.
+	maxManifestDepth = 16
+)
+
+// Array merge strategies supported in the mergeArrays section of a manifest.
+const (
+	arrayMergeReplace = "replace"
+	arrayMergeAppend  = "append"
+	arrayMergePrepend = "prepend"
+	arrayMergeUnion   = "union"
+)
+
+// manifestCacheEntry is a resolved manifest together with the state of every file
+// it was built from, so a change anywhere in the chain invalidates it.
+type manifestCacheEntry struct {
+	root    string
+	content []byte
+	files   map[string]os.FileInfo
+}
+
+var manifestCache = struct {
+	sync.RWMutex
+	entries map[string]*manifestCacheEntry
+	hits    atomic.Int64
+	misses  atomic.Int64
+}{entries: make(map[string]*manifestCacheEntry)}
+
+// readManifest returns the content of a manifest with its inheritance chain resolved,
+// parents are looked up below the manifest root. Manifests that don't declare a parent
+// are returned as stored on disk.
+func readManifest(root, filePath string) ([]byte, error) {
+	manifestCache.RLock()
+	entry, found := manifestCache.entries[filePath]
+	manifestCache.RUnlock()
+	if found && entry.root == root && entry.fresh() {
+		manifestCache.hits.Add(1)
+		return entry.content, nil
+	}
+	manifestCache.misses.Add(1)
+
+	entry = &manifestCacheEntry{root: root, files: make(map[string]os.FileInfo)}
+	content, err := entry.resolve(filePath, nil)
+	if err != nil {
+		return nil, err
+	}
+	entry.content = content
+
+	manifestCache.Lock()
+	manifestCache.entries[filePath] = entry
+	manifestCache.Unlock()
+	return content, nil
+}
+
+// manifestCacheStats returns the number of cache hits, misses and cached manifests.
+func manifestCacheStats() (int64, int64, int) {
+	manifestCache.RLock()
+	entries := len(manifestCache.entries)
+	manifestCache.RUnlock()
+	return manifestCache.hits.Load(), manifestCache.misses.Load(), entries
+}
+
+// fresh reports whether none of the files the entry was built from has changed.
+func (e *manifestCacheEntry) fresh() bool {
+	for filePath, info := range e.files {
+		current, err := os.Stat(filePath)
+		if err != nil || current.Size() != info.Size() || !current.ModTime().Equal(info.ModTime()) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve reads the manifest at filePath and merges it over its parents. The chain
+// holds the manifests currently being resolved and is used to detect cycles.
+func (e *manifestCacheEntry) resolve(filePath string, chain []string) ([]byte, error) {
+	for _, visited := range chain {
+		if visited == filePath {
+			return nil, fmt.Errorf("manifest inheritance cycle: %s", strings.Join(append(chain, filePath), " -> "))
+		}
+	}
+	if len(chain) >= maxManifestDepth {
+		return nil, fmt.Errorf("manifest inheritance deeper than %d: %s", maxManifestDepth, filePath)
+	}
+	chain = append(chain, filePath)
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s", err)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %s", err)
+	}
+	e.files[filePath] = info
+
+	// Only JSON objects can extend another manifest, anything else is served as is.
+	var manifest map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&manifest); err != nil {
+		return content, nil
+	}
+	extends, ok := manifest[manifestExtendsKey].(string)
+	if !ok {
+		return content, nil
+	}
+
+	parentPath, err := manifestParentPath(extends)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filePath, err)
+	}
+	parentContent, err := e.resolve(filepath.Join(e.root, parentPath), chain)
+	if err != nil {
+		return nil, err
+	}
+	var parent interface{}
+	decoder = json.NewDecoder(bytes.NewReader(parentContent))
+	decoder.UseNumber()
+	if err := decoder.Decode(&parent); err != nil {
+		return nil, fmt.Errorf("%s: parent %s is not a JSON manifest: %s", filePath, parentPath, err)
+	}
+
+	strategies, err := manifestArrayStrategies(manifest[manifestArraysKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filePath, err)
+	}
+	delete(manifest, manifestExtendsKey)
+	delete(manifest, manifestArraysKey)
+
+	return json.Marshal(mergeManifest(parent, manifest, "", strategies))
+}
+
+// manifestParentPath converts an extends reference such as "core/1.0.0" into a file path
+// relative to the manifest root.
+func manifestParentPath(extends string) (string, error) {
+	parts := strings.Split(extends, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.HasPrefix(parts[0], ".") || strings.HasPrefix(parts[1], ".") {
+		return "", fmt.Errorf("invalid extends reference: %q", extends)
+	}
+	return filepath.Join(parts[0], parts[1]+".json"), nil
+}
+
+// manifestArrayStrategies validates the mergeArrays section of a manifest.
+func manifestArrayStrategies(section interface{}) (map[string]string, error) {
+	strategies := make(map[string]string)
+	if section == nil {
+		return strategies, nil
+	}
+	fields, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an object", manifestArraysKey)
+	}
+	for path, value := range fields {
+		strategy, _ := value.(string)
+		switch strategy {
+		case arrayMergeReplace, arrayMergeAppend, arrayMergePrepend, arrayMergeUnion:
+			strategies[path] = strategy
+		default:
+			return nil, fmt.Errorf("unknown array merge strategy for %s: %v", path, value)
+		}
+	}
+	return strategies, nil
+}
+
+// mergeManifest deep merges override into base. Objects are merged key by key,
+// arrays follow the strategy configured for their dotted path and any other value
+// in override replaces the one in base.
+func mergeManifest(base, override interface{}, path string, strategies map[string]string) interface{} {
+	switch overrideValue := override.(type) {
+	case map[string]interface{}:
+		baseValue, ok := base.(map[string]interface{})
+		if !ok {
+			return overrideValue
+		}
+		merged := make(map[string]interface{}, len(baseValue)+len(overrideValue))
+		for key, value := range baseValue {
+			merged[key] = value
+		}
+		for key, value := range overrideValue {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			merged[key] = mergeManifest(baseValue[key], value, childPath, strategies)
+		}
+		return merged
+	case []interface{}:
+		baseValue, ok := base.([]interface{})
+		if !ok {
+			return overrideValue
+		}
+		switch strategies[path] {
+		case arrayMergeAppend:
+			return append(append([]interface{}{}, baseValue...), overrideValue...)
+		case arrayMergePrepend:
+			return append(append([]interface{}{}, overrideValue...), baseValue...)
+		case arrayMergeUnion:
+			merged := append([]interface{}{}, baseValue...)
+			for _, value := range overrideValue {
+				if !containsManifestValue(merged, value) {
+					merged = append(merged, value)
+				}
+			}
+			return merged
+		default:
+			return overrideValue
+		}
+	default:
+		return override
+	}
+}
+
+func containsManifestValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestVariants(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		payload  Payload
+		expected []string
+		hasError bool
+	}{
+		{"NoVariant", Payload{}, []string{""}, false},
+		{"PlatformOnly", Payload{Platform: "ios"}, []string{"ios", ""}, false},
+		{"PlatformLocale", Payload{Platform: "android", Locale: "de"}, []string{"android.de", "android", ""}, false},
+		{"AllFields", Payload{Platform: "pc", Locale: "pt-BR", Channel: "beta"}, []string{"pc.pt-BR.beta", "pc.pt-BR", "pc", ""}, false},
+		{"SkipsEmpty", Payload{Locale: "de", Channel: "dev"}, []string{"de.dev", "de", ""}, false},
+		{"PathTraversal", Payload{Platform: "../secret"}, nil, true},
+		{"DotInLocale", Payload{Locale: "de.at"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variants, err := manifestVariants(tt.payload)
+			if tt.hasError {
+				assert.Error(t, err, "Expected an error")
+				return
+			}
+			assert.NoError(t, err, "Expected no error")
+			assert.Equal(t, tt.expected, variants)
+		})
+	}
+}
+
+func TestResolveManifest(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"1.0.0.json", "1.0.0.android.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(`{}`), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	root, base := filepath.Split(dir)
+	filePath, variant, err := resolveManifest(root, Payload{Type: base, Version: "1.0.0", Platform: "android", Locale: "de"})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "1.0.0.android.json"), filePath)
+	assert.Equal(t, "android", variant)
+
+	filePath, variant, err = resolveManifest(root, Payload{Type: base, Version: "1.0.0", Platform: "ios"})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "1.0.0.json"), filePath)
+	assert.Empty(t, variant)
+
+	_, _, err = resolveManifest(root, Payload{Type: base, Version: "9.9.9", Platform: "ios"})
+	assert.Error(t, err)
+}
+
+func TestReadManifestInheritance(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeManifest := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	root, base := filepath.Split(dir)
+	writeManifest("1.0.0.json", `{"name": "base", "limits": {"lives": 5, "coins": 100}, "features": ["a", "b"], "tags": ["x"]}`)
+	writeManifest("1.0.1.json", `{"extends": "`+base+`/1.0.0", "mergeArrays": {"features": "union"}, "limits": {"coins": 200}, "features": ["b", "c"], "tags": ["y"]}`)
+	writeManifest("cycle-a.json", `{"extends": "`+base+`/cycle-b"}`)
+	writeManifest("cycle-b.json", `{"extends": "`+base+`/cycle-a"}`)
+	writeManifest("bad-strategy.json", `{"extends": "`+base+`/1.0.0", "mergeArrays": {"features": "shuffle"}}`)
+
+	content, err := readManifest(root, filepath.Join(dir, "1.0.1.json"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name": "base", "limits": {"lives": 5, "coins": 200}, "features": ["a", "b", "c"], "tags": ["y"]}`, string(content))
+
+	// The resolved manifest is cached until one of the files in the chain changes.
+	cached, err := readManifest(root, filepath.Join(dir, "1.0.1.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, content, cached)
+	writeManifest("1.0.0.json", `{"name": "changed"}`)
+	content, err = readManifest(root, filepath.Join(dir, "1.0.1.json"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name": "changed", "limits": {"coins": 200}, "features": ["b", "c"], "tags": ["y"]}`, string(content))
+
+	_, err = readManifest(root, filepath.Join(dir, "cycle-a.json"))
+	assert.ErrorContains(t, err, "cycle")
+
+	_, err = readManifest(root, filepath.Join(dir, "bad-strategy.json"))
+	assert.ErrorContains(t, err, "unknown array merge strategy")
+}
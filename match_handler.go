@@ -0,0 +1,758 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+const (
+	// xoxoModuleName is the name the xoxo match handler is registered with.
+	xoxoModuleName = "xoxo"
+	// tickRate is the number of match loop calls per second.
+	tickRate = 5
+	// maxEmptySec is how long a match without human players is kept before it ends.
+	maxEmptySec = 30
+	// playersPerMatch is the number of seats in a match, AI included.
+	playersPerMatch = 2
+	// turnTimeFastSec and turnTimeNormalSec are how long a player has to move in the fast
+	// and normal modes before forfeiting the round.
+	turnTimeFastSec   = 10
+	turnTimeNormalSec = 20
+	// delayBetweenGamesSec is the pause between the end of a round and the next one.
+	delayBetweenGamesSec = 5
+	// reconnectGraceSec is how long the seat of a player who dropped out of a round is
+	// kept for them to rejoin.
+	reconnectGraceSec = 15
+	// inviteAIWindowSec is how long the round a player left stays stopped for the remaining
+	// player to invite the AI into it.
+	inviteAIWindowSec = 15
+	// defaultMaxSpectators is the number of spectators a match takes unless created with
+	// the "max_spectators" param.
+	defaultMaxSpectators = 10
+	// metadataSpectator is the join metadata key set to "true" by clients joining to watch.
+	metadataSpectator = "spectator"
+)
+
+// MatchState is the state of an xoxo match carried between match handler calls.
+type MatchState struct {
+	matchID string
+	// tick is the tick of the match loop call in progress.
+	tick   int64
+	random *rand.Rand
+	label  *MatchLabel
+	// encodedLabel is the label last sent to Nakama, so it's only updated on change.
+	encodedLabel string
+	emptyTicks   int
+
+	// presences holds the players by user ID, the AI player included.
+	presences       map[string]runtime.Presence
+	joinsInProgress int
+	// reserved holds the marks of the players a matchmade match was created for, by
+	// user ID. Only they can take the seats and they play their mark in the first round.
+	reserved map[string]api.Mark
+	// spectators holds the presences watching the match by user ID. They get every
+	// broadcast but can't play.
+	spectators    map[string]runtime.Presence
+	maxSpectators int
+	// spectatorJoins holds the user IDs of spectators between join attempt and join.
+	spectatorJoins map[string]bool
+	// disconnected counts down the ticks left for players who dropped out of a round to
+	// rejoin, by user ID. Their seats stay taken meanwhile.
+	disconnected map[string]int64
+	// messageLimiter limits the messages each user sends, violations counts their rejected
+	// messages by reason and kicked holds the users kicked out for too many of them, all
+	// by user ID.
+	messageLimiter *rateLimiter
+	violations     map[string]map[string]int
+	kicked         map[string]bool
+
+	rules gameRules
+	// playing is true while a round is in progress.
+	playing bool
+	board   []api.Mark
+	// marks holds the mark of each player for the current round by user ID.
+	marks map[string]api.Mark
+	// mark is the mark whose turn it is.
+	mark            api.Mark
+	winner          api.Mark
+	winnerPositions []int32
+	// forfeit is the mark of the player who lost the round by running out of time or
+	// leaving, unspecified otherwise.
+	forfeit api.Mark
+	// deadlineRemainingTicks counts down the ticks left for the current turn.
+	deadlineRemainingTicks int64
+	// nextGameRemainingTicks counts down the ticks left before the next round starts.
+	nextGameRemainingTicks int64
+	// bestOf is the number of rounds of a series, seriesRound the number of the current
+	// round in it and scores the rounds won in it by user ID.
+	bestOf      int
+	seriesRound int
+	seriesDone  bool
+	scores      map[string]int32
+	// round counts the rounds started, moves holds the moves of the current round and
+	// replays the rounds finished during the current tick, to be saved at its end.
+	round          int
+	roundStartedAt int64
+	moves          []*ReplayMove
+	replays        []*Replay
+	// leftMark is the mark of the player who left the round, which the AI can take over
+	// when the remaining player invites it. It's unspecified otherwise.
+	leftMark api.Mark
+	// inviteRemainingTicks counts down the ticks left to invite the AI into the round
+	// stopped by a player leaving. The round resumes with the AI or is forfeited by the
+	// player who left, it's 0 when no round is stopped.
+	inviteRemainingTicks int64
+}
+
+// MatchHandler is the authoritative match handler of the xoxo game.
+type MatchHandler struct{}
+
+func newMatchHandler(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule) (runtime.Match, error) {
+	return &MatchHandler{}, nil
+}
+
+// MatchInit creates the match state from the params the match was created with: "fast",
+// "ai", "skill", "region", "marks", the marks of matchmade players by user ID,
+// "max_spectators", "best_of", "game", "xoxo" or "connect4", and for xoxo "size", the
+// width of the board, and "win_length". Invalid game params fail the match creation.
+func (m *MatchHandler) MatchInit(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, params map[string]interface{}) (interface{}, int, string) {
+	fast, _ := params["fast"].(bool)
+	ai, _ := params["ai"].(bool)
+	region, _ := params["region"].(string)
+
+	rules, err := newGameRules(params)
+	if err != nil {
+		logger.Error("error creating match: %v", err)
+		return nil, 0, ""
+	}
+	bestOf := defaultBestOf
+	if value, ok := params["best_of"]; ok {
+		bestOf = intParam(value)
+	}
+	if err := validateBestOf(bestOf); err != nil {
+		logger.Error("error creating match: %v", err)
+		return nil, 0, ""
+	}
+
+	matchID, _ := ctx.Value(runtime.RUNTIME_CTX_MATCH_ID).(string)
+	s := &MatchState{
+		matchID: matchID,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		label: &MatchLabel{
+			Fast:   fast,
+			AI:     ai,
+			Skill:  intParam(params["skill"]),
+			Region: region,
+			BestOf: bestOf,
+		},
+		bestOf:       bestOf,
+		rules:        rules,
+		presences:    make(map[string]runtime.Presence, playersPerMatch),
+		disconnected: make(map[string]int64, playersPerMatch),
+
+		spectators:     make(map[string]runtime.Presence),
+		maxSpectators:  defaultMaxSpectators,
+		spectatorJoins: make(map[string]bool),
+
+		violations: make(map[string]map[string]int),
+		kicked:     make(map[string]bool),
+	}
+	s.messageLimiter = newMessageLimiter(s)
+	switch rules := rules.(type) {
+	case *xoxoRules:
+		s.label.Game = gameXoxo
+		s.label.Size = rules.rows
+		s.label.WinLength = rules.winLength
+	case *connectFourRules:
+		s.label.Game = gameConnectFour
+	}
+	if maxSpectators, ok := params["max_spectators"]; ok {
+		s.maxSpectators = intParam(maxSpectators)
+	}
+	if marks, ok := params["marks"].(map[string]interface{}); ok {
+		s.reserved = make(map[string]api.Mark, len(marks))
+		for userID, mark := range marks {
+			s.reserved[userID] = api.Mark(intParam(mark))
+		}
+	}
+	if ai {
+		s.presences[aiUserID] = aiPresence{}
+	}
+	s.label.Open = s.openSeats()
+	s.encodedLabel = s.label.encode()
+
+	return s, tickRate, s.encodedLabel
+}
+
+func (m *MatchHandler) MatchJoinAttempt(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, dispatcher runtime.MatchDispatcher, tick int64, state interface{}, presence runtime.Presence, metadata map[string]string) (interface{}, bool, string) {
+	s := state.(*MatchState)
+
+	// Users kicked out for repeated violations can't come back.
+	if s.kicked[presence.GetUserId()] {
+		return s, false, "kicked"
+	}
+
+	// Reject users who are already in the match.
+	if _, ok := s.presences[presence.GetUserId()]; ok {
+		return s, false, "already joined"
+	}
+	if _, ok := s.spectators[presence.GetUserId()]; ok || s.spectatorJoins[presence.GetUserId()] {
+		return s, false, "already joined"
+	}
+
+	// Spectators don't take a seat.
+	if metadata[metadataSpectator] == "true" {
+		if len(s.spectators)+len(s.spectatorJoins) >= s.maxSpectators {
+			return s, false, "spectators full"
+		}
+		s.spectatorJoins[presence.GetUserId()] = true
+		return s, true, ""
+	}
+
+	// Players who dropped out of the round get their seat back.
+	if _, ok := s.disconnected[presence.GetUserId()]; ok {
+		s.joinsInProgress++
+		return s, true, ""
+	}
+
+	// Seats of matchmade matches are kept for the matched players.
+	if s.reserved != nil {
+		if _, ok := s.reserved[presence.GetUserId()]; !ok {
+			return s, false, "seat reserved"
+		}
+	}
+
+	// Check if match is full.
+	if len(s.presences)+len(s.disconnected)+s.joinsInProgress >= playersPerMatch {
+		return s, false, "match full"
+	}
+
+	// New player attempting to connect.
+	s.joinsInProgress++
+	return s, true, ""
+}
+
+func (m *MatchHandler) MatchJoin(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, dispatcher runtime.MatchDispatcher, tick int64, state interface{}, presences []runtime.Presence) interface{} {
+	s := state.(*MatchState)
+
+	for _, presence := range presences {
+		if s.spectatorJoins[presence.GetUserId()] {
+			delete(s.spectatorJoins, presence.GetUserId())
+			s.spectators[presence.GetUserId()] = presence
+			continue
+		}
+
+		s.joinsInProgress--
+		// The AI may have taken the last seat since the join attempt was accepted.
+		if _, ok := s.disconnected[presence.GetUserId()]; !ok && len(s.presences) >= playersPerMatch {
+			if err := dispatcher.MatchKick([]runtime.Presence{presence}); err != nil {
+				logger.Error("error kicking %s from full match: %v", presence.GetUserId(), err)
+			}
+			continue
+		}
+		s.emptyTicks = 0
+		s.presences[presence.GetUserId()] = presence
+
+		// Catch a rejoining player up on the round they dropped out of.
+		if _, ok := s.disconnected[presence.GetUserId()]; ok {
+			delete(s.disconnected, presence.GetUserId())
+			if s.playing {
+				m.send(logger, dispatcher, api.OpCode_OPCODE_UPDATE, &api.Update{
+					Board:    s.board,
+					Mark:     s.mark,
+					Deadline: deadlineAfter(s.deadlineRemainingTicks),
+				}, []runtime.Presence{presence})
+			}
+			continue
+		}
+		// A new player taking the seat of one who left ends the round they stopped.
+		m.forfeitStoppedRound(logger, dispatcher, s)
+	}
+	s.updateLabel(logger, dispatcher)
+
+	return s
+}
+
+func (m *MatchHandler) MatchLeave(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, dispatcher runtime.MatchDispatcher, tick int64, state interface{}, presences []runtime.Presence) interface{} {
+	s := state.(*MatchState)
+
+	for _, presence := range presences {
+		if _, ok := s.spectators[presence.GetUserId()]; ok {
+			delete(s.spectators, presence.GetUserId())
+			continue
+		}
+
+		// Presences turned away in MatchJoin never took a seat.
+		if _, ok := s.presences[presence.GetUserId()]; !ok {
+			continue
+		}
+		delete(s.presences, presence.GetUserId())
+		// Keep the seat of a player whose connection dropped during a round in case they
+		// reconnect. Players who left on purpose or were kicked are gone for good.
+		_, seated := s.marks[presence.GetUserId()]
+		if seated && s.playing && presence.GetReason() == runtime.PresenceReasonDisconnect && !s.kicked[presence.GetUserId()] {
+			s.disconnected[presence.GetUserId()] = reconnectGraceSec * tickRate
+			continue
+		}
+		m.playerLeft(logger, dispatcher, s, presence.GetUserId())
+	}
+	s.updateLabel(logger, dispatcher)
+
+	return s
+}
+
+func (m *MatchHandler) MatchLoop(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, dispatcher runtime.MatchDispatcher, tick int64, state interface{}, messages []runtime.MatchData) interface{} {
+	s := state.(*MatchState)
+	s.tick = tick
+
+	// Players who failed to rejoin in time have left for good.
+	for userID := range s.disconnected {
+		s.disconnected[userID]--
+		if s.disconnected[userID] > 0 {
+			continue
+		}
+		delete(s.disconnected, userID)
+		m.playerLeft(logger, dispatcher, s, userID)
+		s.updateLabel(logger, dispatcher)
+	}
+
+	// The player who left forfeits the round once the AI wasn't invited in time.
+	if s.inviteRemainingTicks > 0 {
+		s.inviteRemainingTicks--
+		if s.inviteRemainingTicks == 0 {
+			m.forfeitRound(logger, dispatcher, s, s.leftMark)
+		}
+	}
+
+	// End the match once it has been without human players for too long.
+	if s.humanPlayers() == 0 {
+		// Rounds forfeited by the last player leaving are still recorded.
+		m.recordRounds(ctx, logger, nk, s)
+		s.emptyTicks++
+		if s.emptyTicks >= maxEmptySec*tickRate {
+			logger.Info("closing idle match")
+			return nil
+		}
+		return s
+	}
+
+	// Start a new round once both seats are taken and the pause after the last round is over.
+	if !s.playing && len(s.presences) == playersPerMatch {
+		if s.nextGameRemainingTicks > 0 {
+			s.nextGameRemainingTicks--
+		} else {
+			m.startRound(logger, dispatcher, s)
+		}
+	}
+
+	for _, message := range messages {
+		// Drop what's left of the messages of users kicked out during this tick.
+		if s.kicked[message.GetUserId()] {
+			continue
+		}
+		if !s.messageLimiter.allow(message.GetUserId()) {
+			m.reject(ctx, logger, nk, dispatcher, s, message, errMessageRateLimited)
+			continue
+		}
+
+		switch api.OpCode(message.GetOpCode()) {
+		case api.OpCode_OPCODE_MOVE:
+			move, err := s.validMove(message)
+			if err != nil {
+				m.reject(ctx, logger, nk, dispatcher, s, message, err)
+				continue
+			}
+			if err := m.applyMove(logger, dispatcher, s, move); err != nil {
+				m.reject(ctx, logger, nk, dispatcher, s, message, err)
+			}
+		case api.OpCode_OPCODE_INVITE_AI:
+			if !s.canInviteAI(message) {
+				m.reject(ctx, logger, nk, dispatcher, s, message, errCannotInviteAI)
+				continue
+			}
+			m.inviteAI(logger, dispatcher, s)
+		default:
+			// No other opcodes are expected from the client, so automatically treat it as an error.
+			m.reject(ctx, logger, nk, dispatcher, s, message, errUnexpectedMessage)
+		}
+	}
+
+	// A player who doesn't move in time forfeits the round.
+	if s.playing {
+		s.deadlineRemainingTicks--
+		if s.deadlineRemainingTicks <= 0 {
+			m.forfeitRound(logger, dispatcher, s, s.mark)
+		}
+	}
+
+	m.recordRounds(ctx, logger, nk, s)
+	return s
+}
+
+// recordRounds saves the replays and results of the rounds finished since the last call.
+func (m *MatchHandler) recordRounds(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, s *MatchState) {
+	if len(s.replays) == 0 {
+		return
+	}
+	saveReplays(ctx, logger, nk, s.replays)
+	recordResults(ctx, logger, nk, s.replays)
+	recordStats(ctx, logger, nk, s.replays)
+	recordTournamentResults(ctx, logger, nk, currentConfig().tournaments, s.replays)
+	s.replays = nil
+}
+
+func (m *MatchHandler) MatchTerminate(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, dispatcher runtime.MatchDispatcher, tick int64, state interface{}, graceSeconds int) interface{} {
+	return state
+}
+
+func (m *MatchHandler) MatchSignal(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, dispatcher runtime.MatchDispatcher, tick int64, state interface{}, data string) (interface{}, string) {
+	return state, ""
+}
+
+// startRound clears the board, assigns the marks at random and announces the round.
+func (m *MatchHandler) startRound(logger runtime.Logger, dispatcher runtime.MatchDispatcher, s *MatchState) {
+	userIDs := make([]string, 0, len(s.presences))
+	for userID := range s.presences {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+
+	s.playing = true
+	s.round++
+	s.roundStartedAt = time.Now().Unix()
+	s.moves = nil
+	s.leftMark = api.Mark_MARK_UNSPECIFIED
+	s.board = s.rules.newBoard()
+	switch {
+	case s.marks == nil && s.reserved != nil:
+		// The first round of a matchmade match is played with the marks assigned by the matchmaker.
+		s.marks = make(map[string]api.Mark, len(s.reserved))
+		for userID, mark := range s.reserved {
+			s.marks[userID] = mark
+		}
+		s.newSeries()
+	case s.samePlayers(userIDs):
+		// Players take turns at playing first.
+		s.swapMarks()
+		if s.seriesDone {
+			s.newSeries()
+		}
+	default:
+		s.random.Shuffle(len(userIDs), func(i, j int) {
+			userIDs[i], userIDs[j] = userIDs[j], userIDs[i]
+		})
+		s.marks = map[string]api.Mark{
+			userIDs[0]: api.Mark_MARK_X,
+			userIDs[1]: api.Mark_MARK_O,
+		}
+		s.newSeries()
+	}
+	s.seriesRound++
+	s.mark = api.Mark_MARK_X
+	s.winner = api.Mark_MARK_UNSPECIFIED
+	s.winnerPositions = nil
+	s.forfeit = api.Mark_MARK_UNSPECIFIED
+	s.deadlineRemainingTicks = s.turnTicks()
+
+	m.broadcast(logger, dispatcher, api.OpCode_OPCODE_START, &api.Start{
+		Board:    s.board,
+		Marks:    s.marks,
+		Mark:     s.mark,
+		Deadline: deadlineAfter(s.deadlineRemainingTicks),
+		Scores:   s.copyScores(),
+		Round:    int32(s.seriesRound),
+		BestOf:   int32(s.bestOf),
+	})
+	m.playAI(logger, dispatcher, s)
+}
+
+// validMove returns the move of a move message if it parses, a round is in progress and
+// it's the sender's turn. Whether the move is legal is up to the game rules.
+func (s *MatchState) validMove(message runtime.MatchData) (int32, error) {
+	move := &api.Move{}
+	if err := proto.Unmarshal(message.GetData(), move); err != nil {
+		return 0, errMalformedMessage
+	}
+	if !s.playing {
+		return 0, errGameOver
+	}
+	if s.marks[message.GetUserId()] != s.mark {
+		return 0, errNotYourTurn
+	}
+	return move.Position, nil
+}
+
+// applyMove plays the move for the mark of the current turn and either ends the round
+// or passes the turn to the opponent. Illegal moves are returned the rules' error and
+// leave the board untouched.
+func (m *MatchHandler) applyMove(logger runtime.Logger, dispatcher runtime.MatchDispatcher, s *MatchState, move int32) error {
+	cell, err := s.rules.apply(s.board, move, s.mark)
+	if err != nil {
+		return err
+	}
+	s.moves = append(s.moves, &ReplayMove{
+		Tick:      s.tick,
+		Mark:      s.mark,
+		Position:  move,
+		Timestamp: time.Now().UnixMilli(),
+	})
+
+	if winnerPositions := s.rules.winningLine(s.board, cell); winnerPositions != nil {
+		s.winner = s.mark
+		s.winnerPositions = winnerPositions
+		m.finishRound(logger, dispatcher, s)
+		return nil
+	}
+	if len(s.rules.legalMoves(s.board)) == 0 {
+		m.finishRound(logger, dispatcher, s)
+		return nil
+	}
+
+	s.mark = opponentMark(s.mark)
+	s.deadlineRemainingTicks = s.turnTicks()
+	m.broadcast(logger, dispatcher, api.OpCode_OPCODE_UPDATE, &api.Update{
+		Board:    s.board,
+		Mark:     s.mark,
+		Deadline: deadlineAfter(s.deadlineRemainingTicks),
+	})
+	m.playAI(logger, dispatcher, s)
+	return nil
+}
+
+// forfeitRound ends the round in progress as lost by the player of the mark, who ran
+// out of time or left.
+func (m *MatchHandler) forfeitRound(logger runtime.Logger, dispatcher runtime.MatchDispatcher, s *MatchState, mark api.Mark) {
+	s.winner = opponentMark(mark)
+	s.winnerPositions = nil
+	s.forfeit = mark
+	m.finishRound(logger, dispatcher, s)
+}
+
+// forfeitStoppedRound ends the round stopped by a player leaving, if any, as lost by them.
+func (m *MatchHandler) forfeitStoppedRound(logger runtime.Logger, dispatcher runtime.MatchDispatcher, s *MatchState) {
+	if s.inviteRemainingTicks <= 0 {
+		return
+	}
+	s.inviteRemainingTicks = 0
+	m.forfeitRound(logger, dispatcher, s, s.leftMark)
+}
+
+// finishRound announces the result of the round, the series scores and when the next
+// round starts.
+func (m *MatchHandler) finishRound(logger runtime.Logger, dispatcher runtime.MatchDispatcher, s *MatchState) {
+	s.playing = false
+	s.deadlineRemainingTicks = 0
+	s.nextGameRemainingTicks = delayBetweenGamesSec * tickRate
+	s.replays = append(s.replays, s.replay())
+	seriesWinner := s.scoreRound()
+	m.broadcast(logger, dispatcher, api.OpCode_OPCODE_DONE, &api.Done{
+		Board:           s.board,
+		Winner:          s.winner,
+		WinnerPositions: s.winnerPositions,
+		NextGameStart:   deadlineAfter(s.nextGameRemainingTicks),
+		Scores:          s.copyScores(),
+		SeriesDone:      s.seriesDone,
+		SeriesWinner:    seriesWinner,
+	})
+}
+
+// canInviteAI reports whether the sender can invite the AI to take the seat their
+// opponent left, as long as nobody else took it or is joining to take it and the ai
+// feature is on.
+func (s *MatchState) canInviteAI(message runtime.MatchData) bool {
+	if !featureEnabled(featureAI) || s.playing || s.leftMark == api.Mark_MARK_UNSPECIFIED || len(s.presences)+s.joinsInProgress >= playersPerMatch {
+		return false
+	}
+	_, ok := s.presences[message.GetUserId()]
+	return ok && s.marks[message.GetUserId()] != s.leftMark
+}
+
+// inviteAI seats the AI on the mark of the player who left and resumes the round where
+// it stopped, if they left in the middle of one.
+func (m *MatchHandler) inviteAI(logger runtime.Logger, dispatcher runtime.MatchDispatcher, s *MatchState) {
+	for userID, mark := range s.marks {
+		if mark == s.leftMark {
+			delete(s.marks, userID)
+			// The AI carries on with the series of the player it replaces.
+			s.scores[aiUserID] = s.scores[userID]
+			delete(s.scores, userID)
+		}
+	}
+	s.presences[aiUserID] = aiPresence{}
+	s.marks[aiUserID] = s.leftMark
+	s.leftMark = api.Mark_MARK_UNSPECIFIED
+	s.label.AI = true
+	s.updateLabel(logger, dispatcher)
+
+	// Only a round stopped before its end resumes, otherwise the next round starts as usual.
+	if s.inviteRemainingTicks <= 0 {
+		return
+	}
+	s.inviteRemainingTicks = 0
+	s.playing = true
+	m.broadcast(logger, dispatcher, api.OpCode_OPCODE_UPDATE, &api.Update{
+		Board:    s.board,
+		Mark:     s.mark,
+		Deadline: deadlineAfter(s.deadlineRemainingTicks),
+	})
+	m.playAI(logger, dispatcher, s)
+}
+
+// playAI makes the move of the AI player if it's its turn.
+func (m *MatchHandler) playAI(logger runtime.Logger, dispatcher runtime.MatchDispatcher, s *MatchState) {
+	if !s.playing || s.marks[aiUserID] != s.mark {
+		return
+	}
+	if err := m.applyMove(logger, dispatcher, s, aiMove(s.random, s.rules, s.board, s.mark)); err != nil {
+		logger.Error("error playing AI move: %v", err)
+	}
+}
+
+// playerLeft gives up the seat of a player who left the match for good. A matchmade
+// match stops holding seats so a new player can take the free one. The round in
+// progress, if any, stops and the remaining player is told, so they can invite the AI
+// to take the mark of the player who left. Without anyone left to invite it, the player
+// who left forfeits the round right away.
+func (m *MatchHandler) playerLeft(logger runtime.Logger, dispatcher runtime.MatchDispatcher, s *MatchState, userID string) {
+	s.reserved = nil
+	mark, ok := s.marks[userID]
+	if !ok {
+		return
+	}
+	// A round stopped by the opponent leaving earlier can't resume anymore.
+	m.forfeitStoppedRound(logger, dispatcher, s)
+	s.leftMark = mark
+	if s.playing {
+		s.playing = false
+		s.inviteRemainingTicks = inviteAIWindowSec * tickRate
+		if s.humanPlayers() == 0 {
+			m.forfeitStoppedRound(logger, dispatcher, s)
+		}
+	}
+	if err := dispatcher.BroadcastMessage(int64(api.OpCode_OPCODE_OPPONENT_LEFT), nil, nil, nil, true); err != nil {
+		logger.Error("error broadcasting opponent left: %v", err)
+	}
+}
+
+// reject tells the sender of a message why it was rejected and kicks them out once they
+// committed too many violations.
+func (m *MatchHandler) reject(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, dispatcher runtime.MatchDispatcher, s *MatchState, message runtime.MatchData, err error) {
+	rejected := rejection(message, err)
+	m.send(logger, dispatcher, api.OpCode_OPCODE_REJECTED, rejected, []runtime.Presence{message})
+	if isViolation(err) && s.addViolation(message.GetUserId(), rejected.Reason) {
+		m.kick(ctx, logger, nk, dispatcher, s, message)
+	}
+}
+
+func (m *MatchHandler) broadcast(logger runtime.Logger, dispatcher runtime.MatchDispatcher, opCode api.OpCode, msg proto.Message) {
+	m.send(logger, dispatcher, opCode, msg, nil)
+}
+
+// send sends the message to the presences, or to everyone in the match when nil.
+func (m *MatchHandler) send(logger runtime.Logger, dispatcher runtime.MatchDispatcher, opCode api.OpCode, msg proto.Message, presences []runtime.Presence) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		logger.Error("error encoding message: %v", err)
+		return
+	}
+	if err := dispatcher.BroadcastMessage(int64(opCode), data, presences, nil, true); err != nil {
+		logger.Error("error broadcasting %v: %v", opCode, err)
+	}
+}
+
+// replay returns the replay of the round which just finished.
+func (s *MatchState) replay() *Replay {
+	marks := make(map[string]api.Mark, len(s.marks))
+	for userID, mark := range s.marks {
+		marks[userID] = mark
+	}
+	return &Replay{
+		MatchID:         s.matchID,
+		Round:           s.round,
+		Fast:            s.label.Fast,
+		Game:            s.label.Game,
+		Size:            s.label.Size,
+		WinLength:       s.label.WinLength,
+		Marks:           marks,
+		Moves:           s.moves,
+		Board:           append([]api.Mark(nil), s.board...),
+		Winner:          s.winner,
+		WinnerPositions: s.winnerPositions,
+		Forfeit:         s.forfeit,
+		StartedAt:       s.roundStartedAt,
+		EndedAt:         time.Now().Unix(),
+	}
+}
+
+// turnTicks returns the number of ticks a player has to move in the mode of the match.
+func (s *MatchState) turnTicks() int64 {
+	if s.label.Fast {
+		return turnTimeFastSec * tickRate
+	}
+	return turnTimeNormalSec * tickRate
+}
+
+// deadlineAfter returns the Unix time in seconds at which the given number of ticks
+// will have passed, the form clients get deadlines in.
+func deadlineAfter(ticks int64) int64 {
+	return time.Now().Add(time.Duration(ticks) * time.Second / tickRate).Unix()
+}
+
+// humanPlayers returns the number of players other than the AI.
+func (s *MatchState) humanPlayers() int {
+	if _, ok := s.presences[aiUserID]; ok {
+		return len(s.presences) - 1
+	}
+	return len(s.presences)
+}
+
+// openSeats returns the number of seats anyone can join, which excludes the seats
+// reserved for matchmade players.
+func (s *MatchState) openSeats() int {
+	if s.reserved != nil {
+		return 0
+	}
+	return playersPerMatch - len(s.presences) - len(s.disconnected)
+}
+
+// updateLabel sends the label to Nakama if it changed since it was last sent.
+func (s *MatchState) updateLabel(logger runtime.Logger, dispatcher runtime.MatchDispatcher) {
+	s.label.Open = s.openSeats()
+	s.label.Spectators = len(s.spectators)
+	if encoded := s.label.encode(); encoded != s.encodedLabel {
+		if err := dispatcher.MatchLabelUpdate(encoded); err != nil {
+			logger.Error("error updating label: %v", err)
+			return
+		}
+		s.encodedLabel = encoded
+	}
+}
+
+func opponentMark(mark api.Mark) api.Mark {
+	if mark == api.Mark_MARK_X {
+		return api.Mark_MARK_O
+	}
+	return api.Mark_MARK_X
+}
+
+// intParam reads an integer match param, which is a float64 when it went through JSON.
+func intParam(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+type testPresence struct {
+	userID string
+	reason runtime.PresenceReason
+}
+
+func (p *testPresence) GetHidden() bool                   { return false }
+func (p *testPresence) GetPersistence() bool              { return false }
+func (p *testPresence) GetUsername() string               { return p.userID }
+func (p *testPresence) GetStatus() string                 { return "" }
+func (p *testPresence) GetReason() runtime.PresenceReason { return p.reason }
+func (p *testPresence) GetUserId() string                 { return p.userID }
+func (p *testPresence) GetSessionId() string              { return "session-" + p.userID }
+func (p *testPresence) GetNodeId() string                 { return "node" }
+
+type testMatchData struct {
+	testPresence
+	opCode int64
+	data   []byte
+}
+
+func (d *testMatchData) GetOpCode() int64      { return d.opCode }
+func (d *testMatchData) GetData() []byte       { return d.data }
+func (d *testMatchData) GetReliable() bool     { return true }
+func (d *testMatchData) GetReceiveTime() int64 { return 0 }
+
+type testBroadcast struct {
+	opCode    int64
+	data      []byte
+	presences []runtime.Presence
+}
+
+type testDispatcher struct {
+	broadcasts []*testBroadcast
+	labels     []string
+	kicked     []runtime.Presence
+}
+
+func (d *testDispatcher) BroadcastMessage(opCode int64, data []byte, presences []runtime.Presence, sender runtime.Presence, reliable bool) error {
+	d.broadcasts = append(d.broadcasts, &testBroadcast{opCode: opCode, data: data, presences: presences})
+	return nil
+}
+
+func (d *testDispatcher) BroadcastMessageDeferred(opCode int64, data []byte, presences []runtime.Presence, sender runtime.Presence, reliable bool) error {
+	return d.BroadcastMessage(opCode, data, presences, sender, reliable)
+}
+
+func (d *testDispatcher) MatchKick(presences []runtime.Presence) error {
+	d.kicked = append(d.kicked, presences...)
+	return nil
+}
+
+func (d *testDispatcher) MatchLabelUpdate(label string) error {
+	d.labels = append(d.labels, label)
+	return nil
+}
+
+// last returns the last message broadcast with the opcode, or nil if there is none.
+func (d *testDispatcher) last(opCode api.OpCode) *testBroadcast {
+	for i := len(d.broadcasts) - 1; i >= 0; i-- {
+		if d.broadcasts[i].opCode == int64(opCode) {
+			return d.broadcasts[i]
+		}
+	}
+	return nil
+}
+
+// testMatch drives a match handler through its lifecycle calls.
+type testMatch struct {
+	t          *testing.T
+	handler    *MatchHandler
+	dispatcher *testDispatcher
+	nk         runtime.NakamaModule
+	state      interface{}
+	tick       int64
+	label      string
+}
+
+func newTestMatch(t *testing.T, params map[string]interface{}) *testMatch {
+	m := &testMatch{t: t, handler: &MatchHandler{}, dispatcher: &testDispatcher{}, nk: &testNakamaModule{}}
+	ctx := context.WithValue(context.Background(), runtime.RUNTIME_CTX_MATCH_ID, "match-id.node")
+	m.state, _, m.label = m.handler.MatchInit(ctx, &testLogger{}, &sql.DB{}, m.nk, params)
+	return m
+}
+
+func (m *testMatch) matchState() *MatchState {
+	return m.state.(*MatchState)
+}
+
+func (m *testMatch) join(userID string, metadata map[string]string) (bool, string) {
+	presence := &testPresence{userID: userID}
+	state, accepted, reason := m.handler.MatchJoinAttempt(context.Background(), &testLogger{}, &sql.DB{}, m.nk, m.dispatcher, m.tick, m.state, presence, metadata)
+	m.state = state
+	if accepted {
+		m.state = m.handler.MatchJoin(context.Background(), &testLogger{}, &sql.DB{}, m.nk, m.dispatcher, m.tick, m.state, []runtime.Presence{presence})
+	}
+	return accepted, reason
+}
+
+// leave makes the user leave the match on purpose.
+func (m *testMatch) leave(userID string) {
+	m.state = m.handler.MatchLeave(context.Background(), &testLogger{}, &sql.DB{}, m.nk, m.dispatcher, m.tick, m.state, []runtime.Presence{&testPresence{userID: userID, reason: runtime.PresenceReasonLeave}})
+}
+
+// disconnect makes the user drop out of the match as if their connection was lost.
+func (m *testMatch) disconnect(userID string) {
+	m.state = m.handler.MatchLeave(context.Background(), &testLogger{}, &sql.DB{}, m.nk, m.dispatcher, m.tick, m.state, []runtime.Presence{&testPresence{userID: userID, reason: runtime.PresenceReasonDisconnect}})
+}
+
+func (m *testMatch) loop(messages ...runtime.MatchData) {
+	m.tick++
+	m.state = m.handler.MatchLoop(context.Background(), &testLogger{}, &sql.DB{}, m.nk, m.dispatcher, m.tick, m.state, messages)
+}
+
+func (m *testMatch) move(userID string, position int32) runtime.MatchData {
+	data, err := proto.Marshal(&api.Move{Position: position})
+	if err != nil {
+		m.t.Fatalf("Failed to marshal move: %v", err)
+	}
+	return &testMatchData{testPresence: testPresence{userID: userID}, opCode: int64(api.OpCode_OPCODE_MOVE), data: data}
+}
+
+// players returns the user IDs playing X and O in the current round.
+func (m *testMatch) players() (string, string) {
+	var x, o string
+	for userID, mark := range m.matchState().marks {
+		if mark == api.Mark_MARK_X {
+			x = userID
+		} else {
+			o = userID
+		}
+	}
+	return x, o
+}
+
+func TestMatchHandlerRound(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, map[string]interface{}{"fast": true, "region": "eu"})
+	assert.JSONEq(t, `{"open": 2, "fast": true, "ai": false, "skill": 0, "region": "eu", "spectators": 0, "game": "xoxo", "size": 3, "win_length": 3, "best_of": 1}`, m.label)
+
+	accepted, _ := m.join("user1", nil)
+	assert.True(t, accepted)
+	accepted, _ = m.join("user1", nil)
+	assert.False(t, accepted, "Expected a second join of the same user to be rejected")
+	accepted, _ = m.join("user2", nil)
+	assert.True(t, accepted)
+	accepted, reason := m.join("user3", nil)
+	assert.False(t, accepted)
+	assert.Equal(t, "match full", reason)
+	assert.JSONEq(t, `{"open": 0, "fast": true, "ai": false, "skill": 0, "region": "eu", "spectators": 0, "game": "xoxo", "size": 3, "win_length": 3, "best_of": 1}`, m.dispatcher.labels[len(m.dispatcher.labels)-1])
+
+	m.loop()
+	if !assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_START), "Expected the round to start") {
+		return
+	}
+	x, o := m.players()
+
+	m.loop(m.move(o, 0))
+	assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_REJECTED), "Expected a move out of turn to be rejected")
+
+	m.loop(m.move(x, 0), m.move(o, 0))
+	assert.Equal(t, 2, countBroadcasts(m.dispatcher, api.OpCode_OPCODE_REJECTED), "Expected a move on a taken cell to be rejected")
+
+	m.loop(m.move(o, 3), m.move(x, 1), m.move(o, 4), m.move(x, 2))
+	done := m.dispatcher.last(api.OpCode_OPCODE_DONE)
+	if assert.NotNil(t, done, "Expected the round to end") {
+		result := &api.Done{}
+		assert.NoError(t, proto.Unmarshal(done.data, result))
+		assert.Equal(t, api.Mark_MARK_X, result.Winner)
+		assert.Equal(t, []int32{0, 1, 2}, result.WinnerPositions)
+	}
+	assert.False(t, m.matchState().playing)
+}
+
+func TestMatchHandlerOpponentLeft(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, nil)
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+
+	m.disconnect("user2")
+	assert.Equal(t, 0, m.matchState().label.Open, "Expected the seat to be kept during the grace window")
+	for i := 1; i < reconnectGraceSec*tickRate; i++ {
+		m.loop()
+	}
+	assert.Nil(t, m.dispatcher.last(api.OpCode_OPCODE_OPPONENT_LEFT))
+	m.loop()
+	assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_OPPONENT_LEFT))
+	assert.False(t, m.matchState().playing)
+	assert.Equal(t, 1, m.matchState().label.Open, "Expected the seat to be open again")
+}
+
+func TestMatchHandlerLeave(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, nil)
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+	m.loop(m.move(x, 4))
+
+	m.leave(o)
+	assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_OPPONENT_LEFT), "Expected no grace window for players leaving on purpose")
+	assert.False(t, m.matchState().playing)
+	assert.Empty(t, m.matchState().disconnected)
+	assert.Equal(t, 1, m.matchState().label.Open)
+
+	// A new player taking the free seat ends the stopped round.
+	m.join("user3", nil)
+	assert.Equal(t, api.Mark_MARK_X, m.lastDone().Winner, "Expected the player who left to forfeit the round")
+	assert.Zero(t, m.matchState().inviteRemainingTicks)
+}
+
+func TestMatchHandlerLeaveBetweenRounds(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, map[string]interface{}{
+		"marks": map[string]interface{}{"user1": int(api.Mark_MARK_X), "user2": int(api.Mark_MARK_O)},
+	})
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	m.winRound()
+
+	m.leave("user2")
+	assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_OPPONENT_LEFT))
+	assert.Equal(t, api.Mark_MARK_O, m.matchState().leftMark)
+	assert.Equal(t, 1, m.matchState().label.Open, "Expected the reserved seat to be released")
+
+	m.loop(&testMatchData{testPresence: testPresence{userID: "user1"}, opCode: int64(api.OpCode_OPCODE_INVITE_AI)})
+	assert.Equal(t, 0, countBroadcasts(m.dispatcher, api.OpCode_OPCODE_REJECTED))
+	assert.False(t, m.matchState().playing, "Expected the finished round not to resume")
+	m.nextRound()
+	assert.True(t, m.matchState().playing, "Expected the next round to start with the AI")
+	assert.Contains(t, m.matchState().marks, aiUserID)
+}
+
+func TestMatchHandlerRejoin(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, nil)
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+	m.loop(m.move(x, 4))
+
+	m.disconnect(o)
+	accepted, reason := m.join("user3", nil)
+	assert.False(t, accepted, "Expected the seat to be kept for the player who left")
+	assert.Equal(t, "match full", reason)
+	m.loop()
+	accepted, _ = m.join(o, nil)
+	assert.True(t, accepted)
+
+	update := m.dispatcher.last(api.OpCode_OPCODE_UPDATE)
+	if assert.Len(t, update.presences, 1, "Expected the update to be sent to the rejoining player only") {
+		assert.Equal(t, o, update.presences[0].GetUserId())
+	}
+	msg := &api.Update{}
+	assert.NoError(t, proto.Unmarshal(update.data, msg))
+	assert.Equal(t, api.Mark_MARK_X, msg.Board[4])
+	assert.Equal(t, api.Mark_MARK_O, msg.Mark)
+	assert.Greater(t, msg.Deadline, int64(0))
+
+	m.loop(m.move(o, 0))
+	assert.True(t, m.matchState().playing)
+	assert.Equal(t, api.Mark_MARK_O, m.matchState().board[0], "Expected the round to go on")
+}
+
+func TestMatchHandlerAI(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, map[string]interface{}{"ai": true})
+	assert.Equal(t, 1, m.matchState().label.Open)
+	m.join("user1", nil)
+	m.loop()
+	if !assert.True(t, m.matchState().playing) {
+		return
+	}
+
+	// Whatever the human plays, the AI answers in the same tick until the round ends.
+	for i := 0; i < len(m.matchState().board) && m.matchState().playing; i++ {
+		for position := int32(0); position < int32(len(m.matchState().board)); position++ {
+			if m.matchState().board[position] == api.Mark_MARK_UNSPECIFIED {
+				m.loop(m.move("user1", position))
+				break
+			}
+		}
+	}
+	assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_DONE))
+	assert.Zero(t, countBroadcasts(m.dispatcher, api.OpCode_OPCODE_REJECTED))
+}
+
+func TestAIMove(t *testing.T) {
+	t.Parallel()
+	x, o, e := api.Mark_MARK_X, api.Mark_MARK_O, api.Mark_MARK_UNSPECIFIED
+	random := newTestMatch(t, nil).matchState().random
+	rules, _ := newXoxoRules(3, 3)
+
+	assert.Equal(t, int32(2), aiMove(random, rules, []api.Mark{o, o, e, x, x, e, e, e, e}, o), "Expected the AI to win")
+	assert.Equal(t, int32(5), aiMove(random, rules, []api.Mark{o, e, e, x, x, e, e, e, e}, o), "Expected the AI to block")
+	assert.Equal(t, int32(4), aiMove(random, rules, []api.Mark{x, e, e, e, e, e, e, e, e}, o), "Expected the AI to take the center")
+}
+
+func countBroadcasts(d *testDispatcher, opCode api.OpCode) int {
+	count := 0
+	for _, broadcast := range d.broadcasts {
+		if broadcast.opCode == int64(opCode) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestMatchHandlerTurnTimeout(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, map[string]interface{}{"fast": true})
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	start := &api.Start{}
+	assert.NoError(t, proto.Unmarshal(m.dispatcher.last(api.OpCode_OPCODE_START).data, start))
+	assert.Greater(t, start.Deadline, int64(0))
+
+	// The tick the round started on counts toward the turn time.
+	for i := 2; i < turnTimeFastSec*tickRate; i++ {
+		m.loop()
+	}
+	assert.True(t, m.matchState().playing, "Expected the round to go on until the deadline")
+	m.loop()
+
+	done := &api.Done{}
+	if !assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_DONE), "Expected X to forfeit") {
+		return
+	}
+	assert.NoError(t, proto.Unmarshal(m.dispatcher.last(api.OpCode_OPCODE_DONE).data, done))
+	assert.Equal(t, api.Mark_MARK_O, done.Winner)
+	assert.Empty(t, done.WinnerPositions)
+	assert.Greater(t, done.NextGameStart, int64(0))
+
+	for i := 0; i < delayBetweenGamesSec*tickRate; i++ {
+		m.loop()
+	}
+	assert.False(t, m.matchState().playing, "Expected a pause before the next round")
+	m.loop()
+	assert.True(t, m.matchState().playing)
+	assert.Equal(t, 2, countBroadcasts(m.dispatcher, api.OpCode_OPCODE_START))
+}
+
+func TestMatchHandlerInviteAI(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, nil)
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+	invite := &testMatchData{testPresence: testPresence{userID: x}, opCode: int64(api.OpCode_OPCODE_INVITE_AI)}
+
+	m.loop(invite)
+	assert.Equal(t, 1, countBroadcasts(m.dispatcher, api.OpCode_OPCODE_REJECTED), "Expected the AI not to be invited while the opponent is in the round")
+
+	m.loop(m.move(x, 4))
+	m.disconnect(o)
+	for i := 0; i < reconnectGraceSec*tickRate; i++ {
+		m.loop()
+	}
+	assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_OPPONENT_LEFT))
+	assert.Nil(t, m.dispatcher.last(api.OpCode_OPCODE_DONE), "Expected the round to wait for the invite")
+
+	m.loop(invite)
+	assert.True(t, m.matchState().playing, "Expected the round to resume")
+	assert.Equal(t, api.Mark_MARK_O, m.matchState().marks[aiUserID])
+	assert.True(t, m.matchState().label.AI)
+	assert.Equal(t, api.Mark_MARK_X, m.matchState().board[4], "Expected the board to be kept")
+	assert.Equal(t, 2, countMarks(m.matchState().board), "Expected the AI to have played its turn")
+	assert.Equal(t, api.Mark_MARK_X, m.matchState().mark)
+	assert.Nil(t, m.dispatcher.last(api.OpCode_OPCODE_DONE), "Expected no forfeit once the AI took over")
+}
+
+func TestMatchHandlerInviteAITooLate(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, nil)
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+	m.loop(m.move(x, 4))
+	m.leave(o)
+	for i := 0; i < inviteAIWindowSec*tickRate; i++ {
+		m.loop()
+	}
+	assert.Equal(t, api.Mark_MARK_X, m.lastDone().Winner, "Expected the player who left to forfeit the round")
+
+	m.loop(&testMatchData{testPresence: testPresence{userID: x}, opCode: int64(api.OpCode_OPCODE_INVITE_AI)})
+	assert.False(t, m.matchState().playing, "Expected the forfeited round not to resume")
+	assert.Equal(t, api.Mark_MARK_O, m.matchState().marks[aiUserID])
+	m.nextRound()
+	assert.True(t, m.matchState().playing, "Expected the next round to start with the AI")
+	assert.Equal(t, api.Mark_MARK_X, m.matchState().marks[aiUserID], "Expected the AI to carry on with the series")
+}
+
+func TestMatchHandlerInviteAIWhileJoining(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, nil)
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	m.leave("user2")
+
+	joining := &testPresence{userID: "user3"}
+	state, accepted, _ := m.handler.MatchJoinAttempt(context.Background(), &testLogger{}, &sql.DB{}, m.nk, m.dispatcher, m.tick, m.state, joining, nil)
+	m.state = state
+	assert.True(t, accepted)
+	m.loop(&testMatchData{testPresence: testPresence{userID: "user1"}, opCode: int64(api.OpCode_OPCODE_INVITE_AI)})
+	assert.Equal(t, api.RejectReason_REJECT_REASON_UNSPECIFIED, m.lastRejection(), "Expected the AI not to take a seat being joined")
+	assert.NotContains(t, m.matchState().presences, aiUserID)
+
+	// A seat taken between the join attempt and the join turns the joining player away.
+	m.matchState().presences[aiUserID] = aiPresence{}
+	m.state = m.handler.MatchJoin(context.Background(), &testLogger{}, &sql.DB{}, m.nk, m.dispatcher, m.tick, m.state, []runtime.Presence{joining})
+	assert.Len(t, m.matchState().presences, playersPerMatch)
+	if assert.Len(t, m.dispatcher.kicked, 1) {
+		assert.Equal(t, "user3", m.dispatcher.kicked[0].GetUserId())
+	}
+	assert.Equal(t, 0, m.matchState().joinsInProgress)
+}
+
+func countMarks(board []api.Mark) int {
+	count := 0
+	for _, mark := range board {
+		if mark != api.Mark_MARK_UNSPECIFIED {
+			count++
+		}
+	}
+	return count
+}
+
+func TestMatchHandlerSpectators(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, map[string]interface{}{"max_spectators": 1})
+	spectator := map[string]string{metadataSpectator: "true"}
+	m.join("user1", nil)
+	accepted, _ := m.join("watcher1", spectator)
+	assert.True(t, accepted)
+	accepted, reason := m.join("watcher2", spectator)
+	assert.False(t, accepted)
+	assert.Equal(t, "spectators full", reason)
+	assert.Equal(t, 1, m.matchState().label.Spectators)
+	assert.Equal(t, 1, m.matchState().label.Open, "Expected spectators not to take a seat")
+
+	m.loop()
+	assert.False(t, m.matchState().playing, "Expected spectators not to count as players")
+	m.join("user2", nil)
+	m.loop(m.move("watcher1", 0))
+	assert.True(t, m.matchState().playing)
+	assert.Nil(t, m.dispatcher.last(api.OpCode_OPCODE_START).presences, "Expected spectators to get broadcasts")
+	if rejected := m.dispatcher.last(api.OpCode_OPCODE_REJECTED); assert.NotNil(t, rejected, "Expected spectator moves to be rejected") {
+		assert.Equal(t, "watcher1", rejected.presences[0].GetUserId())
+	}
+	assert.Zero(t, countMarks(m.matchState().board))
+
+	m.leave("watcher1")
+	assert.Equal(t, 0, m.matchState().label.Spectators)
+	assert.True(t, m.matchState().playing)
+}
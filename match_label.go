@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MatchLabel is the label of an xoxo match, kept up to date by the match handler so
+// matches can be listed by the fields below.
+type MatchLabel struct {
+	// Open is the number of seats still available to players.
+	Open int `json:"open"`
+	// Fast is true for matches played with the fast turn duration.
+	Fast bool `json:"fast"`
+	// AI is true for matches against the AI player.
+	AI bool `json:"ai"`
+	// Skill is the skill bucket of the players the match is meant for.
+	Skill int `json:"skill"`
+	// Region is the region the match is hosted for, empty if any.
+	Region string `json:"region"`
+	// Game is the game played in the match, "xoxo" or "connect4".
+	Game string `json:"game"`
+	// Size is the width and height of the board, WinLength the number of marks in a row
+	// which wins a round.
+	Size      int `json:"size"`
+	WinLength int `json:"win_length"`
+	// BestOf is the number of rounds of a series.
+	BestOf int `json:"best_of"`
+	// Spectators is the number of spectators watching the match.
+	Spectators int `json:"spectators"`
+}
+
+// Label fields a match list query can filter on.
+const (
+	labelOpen   = "open"
+	labelFast   = "fast"
+	labelAI     = "ai"
+	labelRegion = "region"
+	labelGame   = "game"
+	labelBestOf = "best_of"
+	labelSize   = "size"
+)
+
+// encode returns the label in the JSON form Nakama indexes.
+func (l *MatchLabel) encode() string {
+	// A struct of plain fields always marshals.
+	label, _ := json.Marshal(l)
+	return string(label)
+}
+
+// labelQuery builds a match list query over label fields, so values are always
+// escaped and field names always prefixed correctly. The first value which can't be
+// put in a query is kept as the error of the query.
+type labelQuery struct {
+	clauses []string
+	err     error
+}
+
+func newLabelQuery() *labelQuery {
+	return &labelQuery{}
+}
+
+// must requires the field to equal the value.
+func (q *labelQuery) must(field string, value interface{}) *labelQuery {
+	return q.addValue("+", field, value)
+}
+
+// should boosts matches whose field equals the value without requiring it.
+func (q *labelQuery) should(field string, value interface{}) *labelQuery {
+	return q.addValue("", field, value)
+}
+
+// between requires a numeric field to be within min and max, both inclusive.
+func (q *labelQuery) between(field string, min, max int) *labelQuery {
+	q.add("+", field, ":>="+strconv.Itoa(min))
+	return q.add("+", field, ":<="+strconv.Itoa(max))
+}
+
+func (q *labelQuery) addValue(operator, field string, value interface{}) *labelQuery {
+	formatted, err := queryValue(value)
+	if err != nil {
+		if q.err == nil {
+			q.err = fmt.Errorf("label field %s: %s", field, err)
+		}
+		return q
+	}
+	return q.add(operator, field, ":"+formatted)
+}
+
+func (q *labelQuery) add(operator, field, condition string) *labelQuery {
+	q.clauses = append(q.clauses, operator+"label."+field+condition)
+	return q
+}
+
+// build returns the query in the syntax accepted by MatchList, or the error of the
+// first value which couldn't be put in it.
+func (q *labelQuery) build() (string, error) {
+	if q.err != nil {
+		return "", q.err
+	}
+	return strings.Join(q.clauses, " "), nil
+}
+
+// queryValue formats a value for a query clause. Strings are quoted so reserved
+// characters and spaces in them don't change the meaning of the query.
+func queryValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	default:
+		return "", fmt.Errorf("unsupported query value %T", value)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelQuery(t *testing.T) {
+	t.Parallel()
+	query := newLabelQuery().
+		must(labelOpen, 1).
+		must(labelFast, true).
+		should(labelRegion, `eu "west"`).
+		between(labelSize, 3, 5)
+	built, err := query.build()
+	assert.NoError(t, err)
+	assert.Equal(t, `+label.open:1 +label.fast:true label.region:"eu \"west\"" +label.size:>=3 +label.size:<=5`, built)
+
+	_, err = newLabelQuery().must(labelSize, 1.5).must(labelOpen, 1).build()
+	assert.Error(t, err, "Expected unsupported values to fail the query")
+}
+
+func TestMatchLabelEncode(t *testing.T) {
+	t.Parallel()
+	label := &MatchLabel{Open: 1, Fast: true, Skill: 3, Region: "eu"}
+	assert.JSONEq(t, `{"open": 1, "fast": true, "ai": false, "skill": 3, "region": "eu", "spectators": 0, "game": "", "size": 0, "win_length": 0, "best_of": 0}`, label.encode())
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+const (
+	// varRegion is the session var the client sends its region in.
+	varRegion = "region"
+	// maxFindMatches is the number of open matches find_match returns at most.
+	maxFindMatches = 10
+)
+
+// errAIDisabled is returned to callers asking to play against the AI while the ai
+// feature is turned off.
+var errAIDisabled = runtime.NewError("playing against the AI is turned off", 9) // FAILED_PRECONDITION
+
+// FindMatch is the find_match RPC function. It returns open xoxo matches of the requested
+// speed, preferring those hosted for the caller's region, and creates a new match when
+// none is open or the caller asked to play against the AI.
+var FindMatch = newRpc("find_match", findMatch)
+
+func findMatch(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, request *api.RpcFindMatchRequest) (*api.RpcFindMatchResponse, error) {
+	vars, _ := ctx.Value(runtime.RUNTIME_CTX_VARS).(map[string]string)
+	region := vars[varRegion]
+
+	if request.Ai && !featureEnabled(featureAI) {
+		return nil, errAIDisabled
+	}
+	if !request.Ai {
+		query := newLabelQuery().
+			between(labelOpen, 1, playersPerMatch).
+			must(labelFast, request.Fast).
+			must(labelAI, false).
+			must(labelGame, gameXoxo).
+			must(labelSize, defaultBoardSize).
+			must(labelBestOf, defaultBestOf)
+		if region != "" {
+			query.should(labelRegion, region)
+		}
+
+		queryString, err := query.build()
+		if err != nil {
+			logger.Error("error building match query: %v", err)
+			return nil, errInternalError
+		}
+		matches, err := nk.MatchList(ctx, maxFindMatches, true, "", nil, nil, queryString)
+		if err != nil {
+			logger.Error("error listing matches: %v", err)
+			return nil, errInternalError
+		}
+		if len(matches) > 0 {
+			matchIDs := make([]string, 0, len(matches))
+			for _, match := range matches {
+				matchIDs = append(matchIDs, match.MatchId)
+			}
+			return &api.RpcFindMatchResponse{MatchIds: matchIDs}, nil
+		}
+	}
+
+	matchID, err := nk.MatchCreate(ctx, xoxoModuleName, map[string]interface{}{
+		"game":   gameXoxo,
+		"fast":   request.Fast,
+		"ai":     request.Ai,
+		"region": region,
+	})
+	if err != nil {
+		logger.Error("error creating match: %v", err)
+		return nil, errInternalError
+	}
+	return &api.RpcFindMatchResponse{MatchIds: []string{matchID}}, nil
+}
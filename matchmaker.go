@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+// Matchmaker ticket properties read when a match is made. "mode" is a string property,
+// "fast" or "normal", "region" a string property and "skill" a numeric one. Tickets only
+// carry string and numeric properties, so the fast flag of find_match is the string
+// "mode" rather than a boolean "fast". There is no "ai" property: the matchmaker pairs
+// human players, find_match with "ai" creates matches against the AI.
+const (
+	propertyMode   = "mode"
+	propertyRegion = "region"
+	propertySkill  = "skill"
+
+	modeFast = "fast"
+)
+
+// MatchmakerMatched creates an authoritative xoxo match for the players the matchmaker
+// paired, with their marks assigned up front so only they can take the seats.
+func MatchmakerMatched(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, entries []runtime.MatchmakerEntry) (string, error) {
+	if len(entries) != playersPerMatch {
+		logger.Error("matchmaker matched %d players, expected %d", len(entries), playersPerMatch)
+		return "", errInternalError
+	}
+
+	matchID, err := nk.MatchCreate(ctx, xoxoModuleName, matchmakerParams(rand.New(rand.NewSource(time.Now().UnixNano())), entries))
+	if err != nil {
+		logger.Error("error creating match: %v", err)
+		return "", errInternalError
+	}
+	return matchID, nil
+}
+
+// matchmakerParams returns the match params for the matched entries. The mode is fast
+// when any player asked for it, the skill bucket is the players' average and the region
+// is kept only when all players share it.
+func matchmakerParams(random *rand.Rand, entries []runtime.MatchmakerEntry) map[string]interface{} {
+	userIDs := make([]string, 0, len(entries))
+	fast := false
+	skill := 0.0
+	region, _ := entries[0].GetProperties()[propertyRegion].(string)
+	for _, entry := range entries {
+		properties := entry.GetProperties()
+		userIDs = append(userIDs, entry.GetPresence().GetUserId())
+		if mode, _ := properties[propertyMode].(string); mode == modeFast {
+			fast = true
+		}
+		entrySkill, _ := properties[propertySkill].(float64)
+		skill += entrySkill
+		if entryRegion, _ := properties[propertyRegion].(string); entryRegion != region {
+			region = ""
+		}
+	}
+
+	sort.Strings(userIDs)
+	random.Shuffle(len(userIDs), func(i, j int) {
+		userIDs[i], userIDs[j] = userIDs[j], userIDs[i]
+	})
+	marks := map[string]interface{}{
+		userIDs[0]: int(api.Mark_MARK_X),
+		userIDs[1]: int(api.Mark_MARK_O),
+	}
+
+	return map[string]interface{}{
+		"fast":   fast,
+		"ai":     false,
+		"skill":  int(skill / float64(len(entries))),
+		"region": region,
+		"marks":  marks,
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+type testMatchmakerEntry struct {
+	presence   *testPresence
+	properties map[string]interface{}
+}
+
+func (e *testMatchmakerEntry) GetPresence() runtime.Presence         { return e.presence }
+func (e *testMatchmakerEntry) GetTicket() string                     { return "ticket-" + e.presence.userID }
+func (e *testMatchmakerEntry) GetProperties() map[string]interface{} { return e.properties }
+func (e *testMatchmakerEntry) GetPartyId() string                    { return "" }
+
+func TestMatchmakerParams(t *testing.T) {
+	t.Parallel()
+	random := rand.New(rand.NewSource(1))
+	entries := []runtime.MatchmakerEntry{
+		&testMatchmakerEntry{&testPresence{userID: "user1"}, map[string]interface{}{"mode": "fast", "region": "eu", "skill": 2.0}},
+		&testMatchmakerEntry{&testPresence{userID: "user2"}, map[string]interface{}{"mode": "normal", "region": "eu", "skill": 5.0}},
+	}
+
+	params := matchmakerParams(random, entries)
+	assert.Equal(t, true, params["fast"])
+	assert.Equal(t, 3, params["skill"])
+	assert.Equal(t, "eu", params["region"])
+	marks := params["marks"].(map[string]interface{})
+	assert.ElementsMatch(t, []interface{}{int(api.Mark_MARK_X), int(api.Mark_MARK_O)}, []interface{}{marks["user1"], marks["user2"]})
+
+	entries[1].GetProperties()["region"] = "us"
+	assert.Equal(t, "", matchmakerParams(random, entries)["region"], "Expected no region for players of different regions")
+}
+
+func TestMatchHandlerReservedSeats(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, map[string]interface{}{
+		"marks": map[string]interface{}{"user1": int(api.Mark_MARK_O), "user2": int(api.Mark_MARK_X)},
+	})
+	assert.Equal(t, 0, m.matchState().label.Open, "Expected a matchmade match not to be listed as open")
+
+	accepted, reason := m.join("user3", nil)
+	assert.False(t, accepted)
+	assert.Equal(t, "seat reserved", reason)
+	accepted, _ = m.join("user1", nil)
+	assert.True(t, accepted)
+	accepted, _ = m.join("user2", nil)
+	assert.True(t, accepted)
+
+	m.loop()
+	x, o := m.players()
+	assert.Equal(t, "user2", x)
+	assert.Equal(t, "user1", o)
+}
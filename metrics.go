@@ -0,0 +1,57 @@
+package main
+
+import (
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// Metric names reported through the Nakama metrics pipeline. Nakama prefixes them
+// with the configured metrics namespace before exporting them to Prometheus.
+const (
+	metricVersionCheck            = "version_checker_requests"
+	metricManifestRead            = "version_checker_manifest_read"
+	metricStorageWrite            = "version_checker_storage_write"
+	metricManifestCacheHitRatio   = "version_checker_manifest_cache_hit_ratio"
+	metricManifestCacheEntryCount = "version_checker_manifest_cache_entries"
+)
+
+// Outcomes of a version check, used as the "outcome" metric tag.
+const (
+	outcomeHit      = "hit"
+	outcomeMismatch = "mismatch"
+	outcomeNotFound = "not_found"
+	outcomeError    = "error"
+)
+
+// tagUnknown is the type and version tag of checks whose manifest didn't resolve, so
+// clients can't create a metric series per made up type or version.
+const tagUnknown = "unknown"
+
+// recordVersionCheck counts a version check by manifest type, version and outcome. The
+// type and version of checks whose manifest didn't resolve are tagged as unknown.
+func recordVersionCheck(nk runtime.NakamaModule, p Payload, resolved bool, outcome string) {
+	manifestType, version := tagUnknown, tagUnknown
+	if resolved {
+		manifestType, version = p.Type, p.Version
+	}
+	nk.MetricsCounterAdd(metricVersionCheck, map[string]string{
+		"type":    manifestType,
+		"version": version,
+		"outcome": outcome,
+	}, 1)
+}
+
+// recordLatency records how long an operation of a version check took.
+func recordLatency(nk runtime.NakamaModule, name string, p Payload, start time.Time) {
+	nk.MetricsTimerRecord(name, map[string]string{"type": p.Type}, time.Since(start))
+}
+
+// recordManifestCache publishes the manifest cache hit ratio and size.
+func recordManifestCache(nk runtime.NakamaModule) {
+	hits, misses, entries := manifestCacheStats()
+	if total := hits + misses; total > 0 {
+		nk.MetricsGaugeSet(metricManifestCacheHitRatio, nil, float64(hits)/float64(total))
+	}
+	nk.MetricsGaugeSet(metricManifestCacheEntryCount, nil, float64(entries))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// metricsTestNakamaModule keeps the tags of the counters added.
+type metricsTestNakamaModule struct {
+	testNakamaModule
+	counters []map[string]string
+}
+
+func (t *metricsTestNakamaModule) MetricsCounterAdd(name string, tags map[string]string, delta int64) {
+	if name == metricVersionCheck {
+		t.counters = append(t.counters, tags)
+	}
+}
+
+func TestRecordVersionCheckTags(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name    string
+		payload string
+		tags    map[string]string
+	}{
+		{"Resolved", `{"type": "core", "version": "1.0.0"}`, map[string]string{"type": "core", "version": "1.0.0", "outcome": outcomeMismatch}},
+		{"NotFound", `{"type": "made_up", "version": "9.9.9"}`, map[string]string{"type": tagUnknown, "version": tagUnknown, "outcome": outcomeNotFound}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			nk := &metricsTestNakamaModule{}
+			_, _ = VersionChecker(context.Background(), &testLogger{}, nil, nk, tt.payload)
+			if assert.Len(t, nk.counters, 1) {
+				assert.Equal(t, tt.tags, nk.counters[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+const (
+	// adminRole is the value of the "role" field in the account metadata of admins.
+	adminRole = "admin"
+	// adminGroupName is the group whose members are admins.
+	adminGroupName = "admins"
+	// groupJoinRequestState is the group membership state of users who only asked to join.
+	groupJoinRequestState = 3
+)
+
+// rpcMiddleware wraps an RPC function with a check or behavior of its own.
+type rpcMiddleware func(next rpcFunc) rpcFunc
+
+// withMiddleware wraps fn in the middlewares, the first one being the outermost.
+func withMiddleware(fn rpcFunc, middlewares ...rpcMiddleware) rpcFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		fn = middlewares[i](fn)
+	}
+	return fn
+}
+
+// contextUserID returns the ID of the user calling, empty for server-to-server calls.
+func contextUserID(ctx context.Context) string {
+	userID, _ := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	return userID
+}
+
+// requireUser rejects calls which aren't made from an authenticated user session.
+func requireUser(next rpcFunc) rpcFunc {
+	return func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+		if contextUserID(ctx) == "" {
+			return "", errNoUserIdFound
+		}
+		return next(ctx, logger, db, nk, payload)
+	}
+}
+
+// requireAdmin lets through users with the admin role in their account metadata or
+// who are members of the admins group. Calls without a user session are rejected, as
+// the http_key they're made with is handed out to clients.
+func requireAdmin(next rpcFunc) rpcFunc {
+	return func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+		userID := contextUserID(ctx)
+		if userID == "" {
+			return "", errPermission
+		}
+		admin, err := isAdmin(ctx, nk, userID)
+		if err != nil {
+			logger.Error("failed to check admin rights of %s: %s", userID, err)
+			return "", errInternalError
+		}
+		if !admin {
+			return "", errPermission
+		}
+		return next(ctx, logger, db, nk, payload)
+	}
+}
+
+// noInput rejects calls which send a payload to RPCs that don't take one.
+func noInput(next rpcFunc) rpcFunc {
+	return func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+		if payload != "" {
+			return "", errNoInputAllowed
+		}
+		return next(ctx, logger, db, nk, payload)
+	}
+}
+
+// isAdmin reports whether the user has the admin role or is a member of the admins group.
+func isAdmin(ctx context.Context, nk runtime.NakamaModule, userID string) (bool, error) {
+	account, err := nk.AccountGetId(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	var metadata struct {
+		Role string `json:"role"`
+	}
+	if account.GetUser().GetMetadata() != "" {
+		if err := json.Unmarshal([]byte(account.GetUser().GetMetadata()), &metadata); err != nil {
+			return false, err
+		}
+	}
+	if metadata.Role == adminRole {
+		return true, nil
+	}
+
+	cursor := ""
+	for {
+		groups, nextCursor, err := nk.UserGroupsList(ctx, userID, 100, nil, cursor)
+		if err != nil {
+			return false, err
+		}
+		for _, group := range groups {
+			if group.GetGroup().GetName() == adminGroupName && group.GetState().GetValue() < groupJoinRequestState {
+				return true, nil
+			}
+		}
+		if nextCursor == "" {
+			return false, nil
+		}
+		cursor = nextCursor
+	}
+}
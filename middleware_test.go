@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// adminTestNakamaModule serves the account metadata and groups of the user under test.
+type adminTestNakamaModule struct {
+	testNakamaModule
+	metadata string
+	groups   []*api.UserGroupList_UserGroup
+}
+
+func (t *adminTestNakamaModule) AccountGetId(ctx context.Context, userID string) (*api.Account, error) {
+	return &api.Account{User: &api.User{Id: userID, Metadata: t.metadata}}, nil
+}
+
+func (t *adminTestNakamaModule) UserGroupsList(ctx context.Context, userID string, limit int, state *int, cursor string) ([]*api.UserGroupList_UserGroup, string, error) {
+	return t.groups, "", nil
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	rpc := func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+		calls = append(calls, "rpc")
+		return "ok", nil
+	}
+	trace := func(name string) rpcMiddleware {
+		return func(next rpcFunc) rpcFunc {
+			return func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+				calls = append(calls, name)
+				return next(ctx, logger, db, nk, payload)
+			}
+		}
+	}
+	serverCtx := context.Background()
+	userCtx := context.WithValue(serverCtx, runtime.RUNTIME_CTX_USER_ID, "user-id")
+	call := func(ctx context.Context, nk runtime.NakamaModule, payload string, middlewares ...rpcMiddleware) error {
+		_, err := withMiddleware(rpc, middlewares...)(ctx, &testLogger{}, &sql.DB{}, nk, payload)
+		return err
+	}
+
+	assert.NoError(t, call(serverCtx, &testNakamaModule{}, "", trace("outer"), trace("inner")))
+	assert.Equal(t, []string{"outer", "inner", "rpc"}, calls, "Expected the first middleware to run first")
+
+	assert.Equal(t, errNoUserIdFound, call(serverCtx, &testNakamaModule{}, "", requireUser))
+	assert.NoError(t, call(userCtx, &testNakamaModule{}, "", requireUser))
+
+	assert.Equal(t, errNoInputAllowed, call(serverCtx, &testNakamaModule{}, "{}", noInput))
+	assert.NoError(t, call(serverCtx, &testNakamaModule{}, "", noInput))
+
+	assert.Equal(t, errPermission, call(serverCtx, &testNakamaModule{}, "", requireAdmin), "Expected calls without a user not to be trusted")
+	assert.Equal(t, errPermission, call(userCtx, &adminTestNakamaModule{metadata: `{"role": "player"}`}, "", requireAdmin))
+	assert.NoError(t, call(userCtx, &adminTestNakamaModule{metadata: `{"role": "admin"}`}, "", requireAdmin))
+	assert.NoError(t, call(userCtx, &adminTestNakamaModule{groups: []*api.UserGroupList_UserGroup{
+		{Group: &api.Group{Name: adminGroupName}, State: wrapperspb.Int32(2)},
+	}}, "", requireAdmin))
+	assert.Equal(t, errPermission, call(userCtx, &adminTestNakamaModule{groups: []*api.UserGroupList_UserGroup{
+		{Group: &api.Group{Name: adminGroupName}, State: wrapperspb.Int32(groupJoinRequestState)},
+	}}, "", requireAdmin), "Expected pending join requests not to count")
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+var errRateLimited = runtime.NewError("too many requests", 8) // RESOURCE_EXHAUSTED
+
+// rateLimiterSweepInterval is how often buckets of idle callers are dropped.
+const rateLimiterSweepInterval = time.Minute
+
+// tokenBucket holds the tokens left for one caller as of the last update.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter is a token bucket rate limiter keyed by caller. Every caller starts with
+// a full bucket of burst tokens which refills at rate tokens per second.
+type rateLimiter struct {
+	sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// setLimits changes the rate and burst, taking effect on the next call of every caller.
+func (l *rateLimiter) setLimits(rate float64, burst int) {
+	l.Lock()
+	l.rate = rate
+	l.burst = float64(burst)
+	l.Unlock()
+}
+
+// allow takes a token from the caller's bucket and reports whether one was available.
+func (l *rateLimiter) allow(key string) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	if l.lastSweep.IsZero() {
+		l.lastSweep = now
+	} else if now.Sub(l.lastSweep) >= rateLimiterSweepInterval {
+		l.sweep(now)
+	}
+
+	bucket, found := l.buckets[key]
+	if !found {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = l.refill(bucket, now)
+	bucket.updated = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+func (l *rateLimiter) refill(bucket *tokenBucket, now time.Time) float64 {
+	tokens := bucket.tokens + now.Sub(bucket.updated).Seconds()*l.rate
+	if tokens > l.burst {
+		return l.burst
+	}
+	return tokens
+}
+
+// sweep drops the buckets that have refilled completely, they're identical to the
+// bucket a new caller starts with.
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		if l.refill(bucket, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// rateLimitKey identifies the caller of an RPC, by user ID for session calls and by
+// client IP for calls made with the server key.
+func rateLimitKey(ctx context.Context) string {
+	if userID := contextUserID(ctx); userID != "" {
+		return "user:" + userID
+	}
+	if clientIP, ok := ctx.Value(runtime.RUNTIME_CTX_CLIENT_IP).(string); ok && clientIP != "" {
+		return "ip:" + clientIP
+	}
+	return ""
+}
+
+// rateLimit returns a middleware rejecting callers who exceed the limiter's rate with
+// RESOURCE_EXHAUSTED before the RPC runs.
+func rateLimit(limiter *rateLimiter) rpcMiddleware {
+	return func(next rpcFunc) rpcFunc {
+		return func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+			key := rateLimitKey(ctx)
+			if !limiter.allow(key) {
+				logger.Warn("rate limit exceeded for %q", key)
+				return "", errRateLimited
+			}
+			return next(ctx, logger, db, nk, payload)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 0)
+	limiter := newRateLimiter(2, 3)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.allow("user:a"), "Expected burst call %d to be allowed", i)
+	}
+	assert.False(t, limiter.allow("user:a"), "Expected call over burst to be rejected")
+	assert.True(t, limiter.allow("user:b"), "Expected other callers to have their own bucket")
+
+	// Two tokens per second refill one token every 500 milliseconds.
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, limiter.allow("user:a"))
+	assert.False(t, limiter.allow("user:a"))
+
+	// Idle callers are dropped once their bucket is full again.
+	now = now.Add(rateLimiterSweepInterval)
+	assert.True(t, limiter.allow("user:c"))
+	assert.Len(t, limiter.buckets, 1)
+}
+
+func TestRateLimitKey(t *testing.T) {
+	t.Parallel()
+	ctx := context.WithValue(context.Background(), runtime.RUNTIME_CTX_CLIENT_IP, "10.0.0.1")
+	assert.Equal(t, "ip:10.0.0.1", rateLimitKey(ctx))
+	ctx = context.WithValue(ctx, runtime.RUNTIME_CTX_USER_ID, "user-id")
+	assert.Equal(t, "user:user-id", rateLimitKey(ctx))
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/rtapi"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+const (
+	// winsLeaderboardID ranks players by the rounds they won against anyone, the AI included.
+	winsLeaderboardID = "xoxo_wins"
+	// ratingLeaderboardID ranks players by their Elo rating.
+	ratingLeaderboardID = "xoxo_rating"
+	// ratingsCollectionName holds the rating of each player under ratingKey.
+	ratingsCollectionName = "xoxo_ratings"
+	ratingKey             = "rating"
+
+	// initialRating is the rating of players who haven't played a rated round.
+	initialRating = 1200.0
+	// eloK is how many points a rated round moves the ratings at most.
+	eloK = 32.0
+	// maxRatingHistory is the number of rating changes kept per player.
+	maxRatingHistory = 20
+	// ratingWriteAttempts is how many times a rating update is tried when the ratings
+	// change concurrently.
+	ratingWriteAttempts = 3
+
+	// propertyRating is the numeric matchmaker property set to the player's rating.
+	propertyRating = "rating"
+)
+
+// Round results recorded in the rating history.
+const (
+	resultWin  = "win"
+	resultLoss = "loss"
+	resultDraw = "draw"
+)
+
+// PlayerRating is the stored rating of a player. Only rounds between two human players
+// are rated.
+type PlayerRating struct {
+	Rating  float64         `json:"rating"`
+	Games   int             `json:"games"`
+	History []*RatingChange `json:"history"`
+}
+
+// RatingChange is the rating change of a player after a rated round.
+type RatingChange struct {
+	Opponent string  `json:"opponent"`
+	Result   string  `json:"result"`
+	Rating   float64 `json:"rating"`
+	Delta    float64 `json:"delta"`
+	Time     int64   `json:"time"`
+}
+
+// RatingRequest represents the payload of the get_rating RPC. The caller's rating is
+// returned when UserID is empty.
+type RatingRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// RatingResponse represents the response of the get_rating RPC. Rank is the position on
+// the rating leaderboard, 0 for players without a rated round.
+type RatingResponse struct {
+	UserID  string          `json:"user_id"`
+	Rating  float64         `json:"rating"`
+	Games   int             `json:"games"`
+	Rank    int64           `json:"rank"`
+	Wins    int64           `json:"wins"`
+	History []*RatingChange `json:"history"`
+}
+
+func (r *RatingRequest) validate() error {
+	if r.UserID != "" && !uuidPattern.MatchString(r.UserID) {
+		return fmt.Errorf("user_id %q is not a valid UUID", r.UserID)
+	}
+	return nil
+}
+
+// createLeaderboards creates the leaderboards results are written to, if they don't exist yet.
+func createLeaderboards(ctx context.Context, nk runtime.NakamaModule) error {
+	if err := nk.LeaderboardCreate(ctx, winsLeaderboardID, true, "desc", "incr", "", nil); err != nil {
+		return err
+	}
+	return nk.LeaderboardCreate(ctx, ratingLeaderboardID, true, "desc", "set", "", nil)
+}
+
+// recordResults adds the wins of the finished rounds to the wins leaderboard and updates
+// the ratings of the players of rounds between two humans. Failures are logged so they
+// don't hold up the match.
+func recordResults(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, replays []*Replay) {
+	for _, replay := range replays {
+		var players []string
+		for userID, mark := range replay.Marks {
+			if userID == aiUserID {
+				continue
+			}
+			players = append(players, userID)
+			if mark == replay.Winner {
+				if _, err := nk.LeaderboardRecordWrite(ctx, winsLeaderboardID, userID, "", 1, 0, nil, nil); err != nil {
+					logger.Error("failed to record win of %s: %s", userID, err)
+				}
+			}
+		}
+		if len(players) != playersPerMatch {
+			continue
+		}
+
+		score := 0.5
+		switch replay.Winner {
+		case replay.Marks[players[0]]:
+			score = 1
+		case replay.Marks[players[1]]:
+			score = 0
+		}
+		if err := updateRatings(ctx, nk, players[0], players[1], score); err != nil {
+			logger.Error("failed to update ratings of %s and %s: %s", players[0], players[1], err)
+		}
+	}
+}
+
+// ratingObjects are the stored ratings, players without one start at the initial rating.
+var ratingObjects = &userObjects[PlayerRating]{
+	collection: ratingsCollectionName,
+	key:        ratingKey,
+	attempts:   ratingWriteAttempts,
+	newValue:   func() *PlayerRating { return &PlayerRating{Rating: initialRating} },
+}
+
+// updateRatings applies the result of a round between two players to their ratings,
+// score being 1 when the first player won, 0 when they lost and 0.5 for a draw. The
+// ratings are written only if neither changed since they were read, which is retried
+// a few times.
+func updateRatings(ctx context.Context, nk runtime.NakamaModule, userID, opponentID string, score float64) error {
+	ratings, err := ratingObjects.update(ctx, nk, func(ratings map[string]*PlayerRating) {
+		rating, opponent := ratings[userID], ratings[opponentID]
+		delta := eloDelta(rating.Rating, opponent.Rating, score)
+		now := time.Now().Unix()
+		rating.addChange(&RatingChange{Opponent: opponentID, Result: ratingResult(score), Delta: delta, Time: now})
+		opponent.addChange(&RatingChange{Opponent: userID, Result: ratingResult(1 - score), Delta: -delta, Time: now})
+	}, userID, opponentID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range []string{userID, opponentID} {
+		if _, err := nk.LeaderboardRecordWrite(ctx, ratingLeaderboardID, id, "", int64(math.Round(ratings[id].Rating)), 0, nil, nil); err != nil {
+			return fmt.Errorf("failed to write rating leaderboard record of %s: %s", id, err)
+		}
+	}
+	return nil
+}
+
+// addChange applies a rating change and keeps it in the history, newest first.
+func (r *PlayerRating) addChange(change *RatingChange) {
+	r.Rating += change.Delta
+	r.Games++
+	change.Rating = r.Rating
+	r.History = append([]*RatingChange{change}, r.History...)
+	if len(r.History) > maxRatingHistory {
+		r.History = r.History[:maxRatingHistory]
+	}
+}
+
+// eloDelta returns the rating change of a player of the rating after scoring score
+// against an opponent of the opponent rating. The opponent's change is its opposite.
+func eloDelta(rating, opponentRating, score float64) float64 {
+	expected := 1 / (1 + math.Pow(10, (opponentRating-rating)/400))
+	return eloK * (score - expected)
+}
+
+func ratingResult(score float64) string {
+	switch score {
+	case 1:
+		return resultWin
+	case 0:
+		return resultLoss
+	default:
+		return resultDraw
+	}
+}
+
+// GetRating returns the rating, rank, wins and rating history of a player.
+var GetRating = newRpc("get_rating", getRating)
+
+func getRating(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, request *RatingRequest) (*RatingResponse, error) {
+	userID := request.UserID
+	if userID == "" {
+		userID = contextUserID(ctx)
+	}
+	if userID == "" {
+		return nil, runtime.NewError("user_id is required", 3) // INVALID_ARGUMENT
+	}
+
+	ratings, _, err := ratingObjects.read(ctx, nk, userID)
+	if err != nil {
+		return nil, err
+	}
+	response := &RatingResponse{
+		UserID:  userID,
+		Rating:  ratings[userID].Rating,
+		Games:   ratings[userID].Games,
+		History: ratings[userID].History,
+	}
+
+	if record, err := ownerRecord(ctx, nk, ratingLeaderboardID, userID); err != nil {
+		return nil, err
+	} else if record != nil {
+		response.Rank = record.Rank
+	}
+	if record, err := ownerRecord(ctx, nk, winsLeaderboardID, userID); err != nil {
+		return nil, err
+	} else if record != nil {
+		response.Wins = record.Score
+	}
+	return response, nil
+}
+
+// ownerRecord returns the record of the user on the leaderboard, nil when there is none.
+func ownerRecord(ctx context.Context, nk runtime.NakamaModule, leaderboardID, userID string) (*api.LeaderboardRecord, error) {
+	_, ownerRecords, _, _, err := nk.LeaderboardRecordsList(ctx, leaderboardID, []string{userID}, 1, "", 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s records: %s", leaderboardID, err)
+	}
+	if len(ownerRecords) == 0 {
+		return nil, nil
+	}
+	return ownerRecords[0], nil
+}
+
+// addRatingProperty sets the player's rating as a numeric matchmaker property, so
+// matchmaker queries can pair players of similar ratings.
+func addRatingProperty(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, in *rtapi.Envelope) (*rtapi.Envelope, error) {
+	add := in.GetMatchmakerAdd()
+	userID := contextUserID(ctx)
+	if add == nil || userID == "" {
+		return in, nil
+	}
+	ratings, _, err := ratingObjects.read(ctx, nk, userID)
+	if err != nil {
+		logger.Error("%s", err)
+		return nil, errInternalError
+	}
+	if add.NumericProperties == nil {
+		add.NumericProperties = make(map[string]float64)
+	}
+	add.NumericProperties[propertyRating] = math.Round(ratings[userID].Rating)
+	return in, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	nkapi "github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/rtapi"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+// conflictingNakamaModule changes the versions of the stored ratings after the second
+// read, so the second rating update fails its first version check.
+type conflictingNakamaModule struct {
+	*storageTestNakamaModule
+	reads int
+}
+
+func (t *conflictingNakamaModule) StorageRead(ctx context.Context, reads []*runtime.StorageRead) ([]*nkapi.StorageObject, error) {
+	objects, err := t.storageTestNakamaModule.StorageRead(ctx, reads)
+	t.reads++
+	if t.reads != 2 {
+		return objects, err
+	}
+	read := make([]*nkapi.StorageObject, 0, len(objects))
+	for _, object := range objects {
+		read = append(read, &nkapi.StorageObject{Collection: object.Collection, Key: object.Key, UserId: object.UserId, Value: object.Value, Version: object.Version})
+		object.Version = "changed"
+	}
+	return read, err
+}
+
+func TestEloDelta(t *testing.T) {
+	t.Parallel()
+	assert.InDelta(t, 16, eloDelta(1200, 1200, 1), 0.001)
+	assert.InDelta(t, 0, eloDelta(1200, 1200, 0.5), 0.001)
+	assert.Less(t, eloDelta(1400, 1200, 1), 16.0, "Expected a favorite to gain less for a win")
+	assert.InDelta(t, -eloDelta(1400, 1200, 0), eloDelta(1200, 1400, 1), 0.001)
+}
+
+func TestRecordResults(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	nk := newStorageTestNakamaModule()
+	m := newTestMatch(t, nil)
+	m.nk = nk
+	m.join("6d1b3a52-0c4e-4c1f-9a51-3f0f2b9f7c01", nil)
+	m.join("6d1b3a52-0c4e-4c1f-9a51-3f0f2b9f7c02", nil)
+	m.loop()
+	m.winRound()
+	winner, loser := m.players()
+
+	payload, _ := json.Marshal(&RatingRequest{UserID: winner})
+	result, err := GetRating(ctx, &testLogger{}, nil, nk, string(payload))
+	if !assert.NoError(t, err) {
+		return
+	}
+	response := &RatingResponse{}
+	assert.NoError(t, json.Unmarshal([]byte(result), response))
+	assert.InDelta(t, 1216, response.Rating, 0.001)
+	assert.Equal(t, 1, response.Games)
+	assert.Equal(t, int64(1), response.Rank)
+	assert.Equal(t, int64(1), response.Wins)
+	if assert.Len(t, response.History, 1) {
+		assert.Equal(t, loser, response.History[0].Opponent)
+		assert.Equal(t, resultWin, response.History[0].Result)
+	}
+
+	userCtx := context.WithValue(ctx, runtime.RUNTIME_CTX_USER_ID, loser)
+	result, err = GetRating(userCtx, &testLogger{}, nil, nk, "")
+	if assert.NoError(t, err) {
+		assert.NoError(t, json.Unmarshal([]byte(result), response))
+		assert.InDelta(t, 1184, response.Rating, 0.001)
+		assert.Equal(t, int64(2), response.Rank)
+		assert.Equal(t, int64(0), response.Wins)
+	}
+
+	_, err = GetRating(ctx, &testLogger{}, nil, nk, "")
+	assert.Equal(t, 3, rpcErrorCode(err), "Expected a user ID to be required for server calls")
+
+	payload, _ = json.Marshal(&RatingRequest{UserID: "user1"})
+	_, err = GetRating(ctx, &testLogger{}, nil, nk, string(payload))
+	assert.Equal(t, 3, rpcErrorCode(err), "Expected a user ID which isn't a UUID to be rejected")
+}
+
+func TestRecordResultsAI(t *testing.T) {
+	t.Parallel()
+	nk := newStorageTestNakamaModule()
+	recordResults(context.Background(), &testLogger{}, nk, []*Replay{{Marks: map[string]api.Mark{"user1": api.Mark_MARK_X, aiUserID: api.Mark_MARK_O}, Winner: api.Mark_MARK_X}})
+	assert.Equal(t, int64(1), nk.records[winsLeaderboardID]["user1"].Score)
+	assert.Empty(t, nk.objects, "Expected rounds against the AI not to be rated")
+}
+
+func TestUpdateRatingsConflict(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	nk := &conflictingNakamaModule{storageTestNakamaModule: newStorageTestNakamaModule()}
+	assert.NoError(t, updateRatings(ctx, nk, "user1", "user2", 1))
+	assert.NoError(t, updateRatings(ctx, nk, "user1", "user2", 1), "Expected the update to be retried")
+	assert.Equal(t, 3, nk.reads)
+
+	ratings, _, err := ratingObjects.read(ctx, nk, "user1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, ratings["user1"].Games, "Expected the rating to be updated once per round")
+	}
+}
+
+func TestAddRatingProperty(t *testing.T) {
+	t.Parallel()
+	ctx := context.WithValue(context.Background(), runtime.RUNTIME_CTX_USER_ID, "user1")
+	nk := newStorageTestNakamaModule()
+	in := &rtapi.Envelope{Message: &rtapi.Envelope_MatchmakerAdd{MatchmakerAdd: &rtapi.MatchmakerAdd{Query: "*"}}}
+
+	out, err := addRatingProperty(ctx, &testLogger{}, &sql.DB{}, nk, in)
+	if assert.NoError(t, err) {
+		assert.Equal(t, initialRating, out.GetMatchmakerAdd().NumericProperties[propertyRating])
+	}
+}
+
+func TestRecordResultsLeave(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	nk := newStorageTestNakamaModule()
+	m := newTestMatch(t, nil)
+	m.nk = nk
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+	m.loop(m.move(x, 4))
+
+	// X leaves on O's turn and loses the round once O doesn't invite the AI.
+	m.leave(x)
+	for i := 0; i < inviteAIWindowSec*tickRate; i++ {
+		m.loop()
+	}
+	ratings, _, err := ratingObjects.read(ctx, nk, x, o)
+	if assert.NoError(t, err) {
+		assert.InDelta(t, 1184, ratings[x].Rating, 0.001, "Expected leaving not to avoid the rating loss")
+		assert.InDelta(t, 1216, ratings[o].Rating, 0.001)
+	}
+
+	payload, _ := json.Marshal(&ReplayRequest{MatchID: "match-id.node"})
+	result, err := GetReplay(ctx, &testLogger{}, nil, nk, string(payload))
+	if assert.NoError(t, err) {
+		response := &ReplayResponse{}
+		assert.NoError(t, json.Unmarshal([]byte(result), response))
+		assert.Equal(t, api.Mark_MARK_X, response.Replay.Forfeit)
+		assert.True(t, response.Verified, response.VerificationError)
+	}
+}
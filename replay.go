@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+// replaysCollectionName holds one object per finished round, keyed by match ID and
+// round number and owned by the system user. Objects are only readable by the server.
+const replaysCollectionName = "xoxo_replays"
+
+// Replay is the record of a finished round: the game it was played with, every
+// accepted move in order and the result sent in Done.
+type Replay struct {
+	MatchID string `json:"match_id"`
+	Round   int    `json:"round"`
+	// Fast, Game, Size and WinLength are the params the round was played with.
+	Fast      bool                `json:"fast,omitempty"`
+	Game      string              `json:"game"`
+	Size      int                 `json:"size,omitempty"`
+	WinLength int                 `json:"win_length,omitempty"`
+	Marks     map[string]api.Mark `json:"marks"`
+	Moves     []*ReplayMove       `json:"moves"`
+	// Board, Winner and WinnerPositions are the result of the round.
+	Board           []api.Mark `json:"board"`
+	Winner          api.Mark   `json:"winner"`
+	WinnerPositions []int32    `json:"winner_positions"`
+	// Forfeit is the mark of the player who lost by running out of time or leaving.
+	Forfeit   api.Mark `json:"forfeit,omitempty"`
+	StartedAt int64    `json:"started_at"`
+	EndedAt   int64    `json:"ended_at"`
+}
+
+// ReplayMove is an accepted move of a round.
+type ReplayMove struct {
+	Tick     int64    `json:"tick"`
+	Mark     api.Mark `json:"mark"`
+	Position int32    `json:"position"`
+	// Timestamp is the Unix time in milliseconds the move was played at.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// ReplayRequest represents the payload of the get_replay RPC.
+type ReplayRequest struct {
+	MatchID string `json:"match_id"`
+	Round   int    `json:"round" default:"1"`
+}
+
+// ReplayResponse represents the response of the get_replay RPC. Verified tells whether
+// the moves reproduce the recorded result, VerificationError why they don't.
+type ReplayResponse struct {
+	Replay            *Replay `json:"replay"`
+	Verified          bool    `json:"verified"`
+	VerificationError string  `json:"verification_error,omitempty"`
+}
+
+func (r *ReplayRequest) validate() error {
+	if r.MatchID == "" {
+		return errors.New("match_id is required")
+	}
+	if r.Round < 1 {
+		return errors.New("round must be positive")
+	}
+	return nil
+}
+
+// replayKey returns the storage key of the replay of a round.
+func replayKey(matchID string, round int) string {
+	return fmt.Sprintf("%s/%d", matchID, round)
+}
+
+// saveReplays writes the replays of the rounds finished since the last call. Replays
+// which fail to save are logged and dropped so they don't hold up the match.
+func saveReplays(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, replays []*Replay) {
+	writes := make([]*runtime.StorageWrite, 0, len(replays))
+	for _, replay := range replays {
+		value, err := json.Marshal(replay)
+		if err != nil {
+			logger.Error("failed to marshal replay of round %d: %s", replay.Round, err)
+			continue
+		}
+		writes = append(writes, &runtime.StorageWrite{
+			Collection:      replaysCollectionName,
+			Key:             replayKey(replay.MatchID, replay.Round),
+			UserID:          currentConfig().SystemUserID,
+			Value:           string(value),
+			PermissionRead:  0, // No client read.
+			PermissionWrite: 0, // No client write.
+		})
+	}
+	if len(writes) == 0 {
+		return
+	}
+	if _, err := nk.StorageWrite(ctx, writes); err != nil {
+		logger.Error("failed to save replays: %s", err)
+	}
+}
+
+// GetReplay returns the replay of a round and whether replaying its moves reproduces
+// the recorded result, for dispute handling and highlights.
+var GetReplay = newRpc("get_replay", getReplay)
+
+func getReplay(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, request *ReplayRequest) (*ReplayResponse, error) {
+	objects, err := nk.StorageRead(ctx, []*runtime.StorageRead{{
+		Collection: replaysCollectionName,
+		Key:        replayKey(request.MatchID, request.Round),
+		UserID:     currentConfig().SystemUserID,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay: %s", err)
+	}
+	if len(objects) == 0 {
+		return nil, runtime.NewError("replay not found", 5) // NOT_FOUND
+	}
+
+	replay := &Replay{}
+	if err := json.Unmarshal([]byte(objects[0].Value), replay); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal replay %s: %s", objects[0].Key, err)
+	}
+	response := &ReplayResponse{Replay: replay, Verified: true}
+	if err := verifyReplay(replay); err != nil {
+		logger.Warn("replay %s doesn't reproduce its result: %s", objects[0].Key, err)
+		response.Verified = false
+		response.VerificationError = err.Error()
+	}
+	return response, nil
+}
+
+// verifyReplay plays the moves of the replay again with the rules of its game and
+// checks they reproduce the recorded board and result. A round which ended without a
+// complete line or a full board must have been forfeited by the recorded player.
+func verifyReplay(replay *Replay) error {
+	rules, err := newGameRules(map[string]interface{}{
+		"game":       replay.Game,
+		"size":       replay.Size,
+		"win_length": replay.WinLength,
+	})
+	if err != nil {
+		return err
+	}
+
+	board := rules.newBoard()
+	mark := api.Mark_MARK_X
+	var winnerPositions []int32
+	for i, move := range replay.Moves {
+		if winnerPositions != nil {
+			return fmt.Errorf("move %d played after the round was won", i+1)
+		}
+		if move.Mark != mark {
+			return fmt.Errorf("move %d played out of turn by %v", i+1, move.Mark)
+		}
+		cell, err := rules.apply(board, move.Position, mark)
+		if err != nil {
+			return fmt.Errorf("move %d is illegal: %s", i+1, err)
+		}
+		winnerPositions = rules.winningLine(board, cell)
+		if winnerPositions == nil {
+			mark = opponentMark(mark)
+		}
+	}
+
+	winner := api.Mark_MARK_UNSPECIFIED
+	switch {
+	case winnerPositions != nil:
+		winner = mark
+	case len(rules.legalMoves(board)) > 0:
+		if replay.Forfeit == api.Mark_MARK_UNSPECIFIED {
+			return errors.New("round ended with legal moves left and no forfeit")
+		}
+		winner = opponentMark(replay.Forfeit)
+	}
+	if !reflect.DeepEqual(board, replay.Board) {
+		return errors.New("board doesn't match")
+	}
+	if winner != replay.Winner {
+		return fmt.Errorf("winner %v doesn't match the recorded %v", winner, replay.Winner)
+	}
+	if !reflect.DeepEqual(winnerPositions, replay.WinnerPositions) {
+		return errors.New("winner positions don't match")
+	}
+	return nil
+}
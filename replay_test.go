@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	nkapi "github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+// storageTestNakamaModule keeps storage objects and leaderboard records in memory.
+// Writes check object versions the way Nakama does.
+type storageTestNakamaModule struct {
+	testNakamaModule
+	objects map[string]*nkapi.StorageObject
+	records map[string]map[string]*nkapi.LeaderboardRecord
+	version int
+}
+
+func newStorageTestNakamaModule() *storageTestNakamaModule {
+	return &storageTestNakamaModule{
+		objects: make(map[string]*nkapi.StorageObject),
+		records: make(map[string]map[string]*nkapi.LeaderboardRecord),
+	}
+}
+
+func (t *storageTestNakamaModule) StorageWrite(ctx context.Context, writes []*runtime.StorageWrite) ([]*nkapi.StorageObjectAck, error) {
+	for _, write := range writes {
+		object, found := t.objects[write.Collection+"/"+write.UserID+"/"+write.Key]
+		if (write.Version == "*" && found) || (write.Version != "" && write.Version != "*" && (!found || object.Version != write.Version)) {
+			return nil, errors.New("storage write rejected - version check failed")
+		}
+	}
+	acks := make([]*nkapi.StorageObjectAck, 0, len(writes))
+	for _, write := range writes {
+		t.version++
+		version := strconv.Itoa(t.version)
+		t.objects[write.Collection+"/"+write.UserID+"/"+write.Key] = &nkapi.StorageObject{
+			Collection: write.Collection,
+			Key:        write.Key,
+			UserId:     write.UserID,
+			Value:      write.Value,
+			Version:    version,
+		}
+		acks = append(acks, &nkapi.StorageObjectAck{Collection: write.Collection, Key: write.Key, UserId: write.UserID, Version: version})
+	}
+	return acks, nil
+}
+
+func (t *storageTestNakamaModule) StorageRead(ctx context.Context, reads []*runtime.StorageRead) ([]*nkapi.StorageObject, error) {
+	var objects []*nkapi.StorageObject
+	for _, read := range reads {
+		if object, ok := t.objects[read.Collection+"/"+read.UserID+"/"+read.Key]; ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+// LeaderboardRecordWrite adds to the score of the leaderboards whose ID ends in "wins"
+// and sets it on the others.
+func (t *storageTestNakamaModule) LeaderboardRecordWrite(ctx context.Context, id string, ownerID string, username string, score int64, subscore int64, metadata map[string]interface{}, overrideOperator *int) (*nkapi.LeaderboardRecord, error) {
+	if t.records[id] == nil {
+		t.records[id] = make(map[string]*nkapi.LeaderboardRecord)
+	}
+	record, ok := t.records[id][ownerID]
+	if !ok {
+		record = &nkapi.LeaderboardRecord{LeaderboardId: id, OwnerId: ownerID}
+		t.records[id][ownerID] = record
+	}
+	if strings.HasSuffix(id, "wins") {
+		record.Score += score
+	} else {
+		record.Score = score
+	}
+	return record, nil
+}
+
+// LeaderboardRecordsList ranks the records by descending score.
+func (t *storageTestNakamaModule) LeaderboardRecordsList(ctx context.Context, id string, ownerIDs []string, limit int, cursor string, expiry int64) (records []*nkapi.LeaderboardRecord, ownerRecords []*nkapi.LeaderboardRecord, nextCursor string, prevCursor string, err error) {
+	for _, record := range t.records[id] {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Score > records[j].Score })
+	for i, record := range records {
+		record.Rank = int64(i + 1)
+	}
+	for _, ownerID := range ownerIDs {
+		if record, ok := t.records[id][ownerID]; ok {
+			ownerRecords = append(ownerRecords, record)
+		}
+	}
+	return records, ownerRecords, "", "", nil
+}
+
+func TestReplayRecording(t *testing.T) {
+	t.Parallel()
+	nk := newStorageTestNakamaModule()
+	m := newTestMatch(t, nil)
+	m.nk = nk
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+	m.loop(m.move(x, 0), m.move(o, 3), m.move(x, 1), m.move(o, 4), m.move(x, 2))
+
+	ctx := context.Background()
+	payload, _ := json.Marshal(&ReplayRequest{MatchID: "match-id.node"})
+	result, err := GetReplay(ctx, &testLogger{}, nil, nk, string(payload))
+	if !assert.NoError(t, err) {
+		return
+	}
+	response := &ReplayResponse{}
+	assert.NoError(t, json.Unmarshal([]byte(result), response))
+	assert.True(t, response.Verified, response.VerificationError)
+	assert.Equal(t, 1, response.Replay.Round)
+	assert.Len(t, response.Replay.Moves, 5)
+	assert.Equal(t, int64(2), response.Replay.Moves[0].Tick)
+	assert.Equal(t, api.Mark_MARK_X, response.Replay.Winner)
+	assert.Equal(t, []int32{0, 1, 2}, response.Replay.WinnerPositions)
+	assert.Equal(t, map[string]api.Mark{x: api.Mark_MARK_X, o: api.Mark_MARK_O}, response.Replay.Marks)
+
+	payload, _ = json.Marshal(&ReplayRequest{MatchID: "match-id.node", Round: 2})
+	_, err = GetReplay(ctx, &testLogger{}, nil, nk, string(payload))
+	assert.Equal(t, 5, rpcErrorCode(err), "Expected NOT_FOUND for a round not played")
+}
+
+func TestVerifyReplay(t *testing.T) {
+	t.Parallel()
+	x, o, e := api.Mark_MARK_X, api.Mark_MARK_O, api.Mark_MARK_UNSPECIFIED
+	moves := func(positions ...int32) []*ReplayMove {
+		var moves []*ReplayMove
+		mark := x
+		for _, position := range positions {
+			moves = append(moves, &ReplayMove{Mark: mark, Position: position})
+			mark = opponentMark(mark)
+		}
+		return moves
+	}
+
+	won := &Replay{
+		Game:            gameXoxo,
+		Size:            3,
+		WinLength:       3,
+		Moves:           moves(0, 3, 1, 4, 2),
+		Board:           []api.Mark{x, x, x, o, o, e, e, e, e},
+		Winner:          x,
+		WinnerPositions: []int32{0, 1, 2},
+	}
+	assert.NoError(t, verifyReplay(won))
+
+	forfeit := &Replay{Game: gameXoxo, Size: 3, WinLength: 3, Moves: moves(4), Board: []api.Mark{e, e, e, e, x, e, e, e, e}, Winner: x}
+	assert.Error(t, verifyReplay(forfeit), "Expected an unfinished round without a forfeit to be caught")
+
+	forfeit.Forfeit = o
+	assert.NoError(t, verifyReplay(forfeit), "Expected O to have forfeited")
+
+	forfeit.Winner = o
+	assert.Error(t, verifyReplay(forfeit))
+
+	tampered := *won
+	tampered.Moves = moves(0, 0, 1, 4, 2)
+	assert.Error(t, verifyReplay(&tampered), "Expected a move on a taken cell to be caught")
+
+	tampered.Moves = moves(0, 3, 1, 4, 2)
+	tampered.Moves[1].Mark = x
+	assert.Error(t, verifyReplay(&tampered), "Expected a move out of turn to be caught")
+
+	connectFour := &Replay{Game: gameConnectFour, Moves: moves(0, 1, 0, 1, 0, 1, 0), Board: newConnectFourRules().newBoard(), Winner: x, WinnerPositions: []int32{14, 21, 28, 35}}
+	for _, cell := range []int32{14, 21, 28, 35} {
+		connectFour.Board[cell] = x
+	}
+	for _, cell := range []int32{29, 36, 22} {
+		connectFour.Board[cell] = o
+	}
+	assert.NoError(t, verifyReplay(connectFour))
+}
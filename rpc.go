@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// metricRpc counts RPC calls by RPC name and returned error code.
+const metricRpc = "rpc_requests"
+
+// rpcFunc is the signature Nakama expects from a registered RPC function.
+type rpcFunc = func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error)
+
+// rpcHandler is an RPC implementation working on typed requests and responses.
+type rpcHandler[Req any, Resp any] func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, req *Req) (*Resp, error)
+
+// validator is implemented by RPC requests which check their own fields. The error
+// is returned to the client as INVALID_ARGUMENT.
+type validator interface {
+	validate() error
+}
+
+// newRpc adapts a typed RPC handler to the function signature Nakama registers. The
+// payload is decoded into a new request, fields still empty get the value of their
+// `default` struct tag and the request is validated before the handler runs. Errors
+// which aren't a runtime.Error are logged and returned as INTERNAL, panics are
+// recovered the same way. Every log line of the call carries the RPC name and a
+// request ID so the lines of one call can be told apart.
+func newRpc[Req any, Resp any](name string, handler rpcHandler[Req, Resp]) rpcFunc {
+	return func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (result string, err error) {
+		logger = logger.WithFields(map[string]interface{}{
+			"rpc":        name,
+			"request_id": newRequestID(),
+		})
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("panic: %v\n%s", r, debug.Stack())
+				result, err = "", errInternalError
+			}
+			nk.MetricsCounterAdd(metricRpc, map[string]string{"rpc": name, "code": strconv.Itoa(rpcErrorCode(err))}, 1)
+			logger.Debug("finished in %d msec", time.Since(start).Milliseconds())
+		}()
+
+		req := new(Req)
+		if payload != "" {
+			if err := json.Unmarshal([]byte(payload), req); err != nil {
+				logger.Error("problem with unmarshal: %s", err)
+				return "", errUnmarshal
+			}
+		}
+		if err := applyDefaults(req); err != nil {
+			logger.Error("problem with defaults: %s", err)
+			return "", errInternalError
+		}
+		if v, ok := interface{}(req).(validator); ok {
+			if err := v.validate(); err != nil {
+				return "", runtime.NewError(err.Error(), 3) // INVALID_ARGUMENT
+			}
+		}
+
+		resp, err := handler(ctx, logger, db, nk, req)
+		if err != nil {
+			var runtimeErr *runtime.Error
+			if errors.As(err, &runtimeErr) {
+				return "", runtimeErr
+			}
+			logger.Error("%s", err)
+			return "", errInternalError
+		}
+
+		responseJSON, err := json.Marshal(resp)
+		if err != nil {
+			logger.Error("failed to marshal response: %s", err)
+			return "", errMarshal
+		}
+		return string(responseJSON), nil
+	}
+}
+
+// newRequestID returns a random identifier for the log lines of one RPC call.
+func newRequestID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
+
+// rpcErrorCode returns the gRPC status code an RPC error is reported with.
+func rpcErrorCode(err error) int {
+	if err == nil {
+		return 0 // OK
+	}
+	var runtimeErr *runtime.Error
+	if errors.As(err, &runtimeErr) {
+		return runtimeErr.Code
+	}
+	return 13 // INTERNAL
+}
+
+// applyDefaults sets the zero valued string, integer and float fields of the struct
+// v points to, to the value of their `default` struct tag.
+func applyDefaults(v interface{}) error {
+	value := reflect.ValueOf(v).Elem()
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		tag, ok := value.Type().Field(i).Tag.Lookup("default")
+		if !ok || !field.CanSet() || !field.IsZero() {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(tag)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			parsed, err := strconv.ParseInt(tag, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid default for %s: %s", value.Type().Field(i).Name, err)
+			}
+			field.SetInt(parsed)
+		case reflect.Float32, reflect.Float64:
+			parsed, err := strconv.ParseFloat(tag, 64)
+			if err != nil {
+				return fmt.Errorf("invalid default for %s: %s", value.Type().Field(i).Name, err)
+			}
+			field.SetFloat(parsed)
+		default:
+			return fmt.Errorf("unsupported default for %s of kind %s", value.Type().Field(i).Name, field.Kind())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRpcRequest struct {
+	Name  string `json:"name" default:"anonymous"`
+	Count int    `json:"count" default:"3"`
+	Fail  string `json:"fail"`
+}
+
+func (r *testRpcRequest) validate() error {
+	if r.Count > 10 {
+		return errors.New("count must not exceed 10")
+	}
+	return nil
+}
+
+type testRpcResponse struct {
+	Greeting string `json:"greeting"`
+	Count    int    `json:"count"`
+}
+
+func TestNewRpc(t *testing.T) {
+	t.Parallel()
+	rpc := newRpc("Test", func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, req *testRpcRequest) (*testRpcResponse, error) {
+		switch req.Fail {
+		case "runtime":
+			return nil, errNoInputAllowed
+		case "plain":
+			return nil, errors.New("disk on fire")
+		case "panic":
+			panic("boom")
+		}
+		return &testRpcResponse{Greeting: "hello " + req.Name, Count: req.Count}, nil
+	})
+	call := func(payload string) (string, error) {
+		return rpc(context.Background(), &testLogger{}, &sql.DB{}, &testNakamaModule{}, payload)
+	}
+
+	result, err := call("")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"greeting": "hello anonymous", "count": 3}`, result, "Expected defaults for an empty payload")
+
+	result, err = call(`{"name": "xoxo", "count": 7}`)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"greeting": "hello xoxo", "count": 7}`, result)
+
+	_, err = call(`invalid_json`)
+	assert.Equal(t, errUnmarshal, err)
+
+	_, err = call(`{"count": 11}`)
+	if assert.Error(t, err) {
+		assert.Equal(t, 3, rpcErrorCode(err), "Expected validation errors to be INVALID_ARGUMENT")
+	}
+
+	_, err = call(`{"fail": "runtime"}`)
+	assert.Equal(t, errNoInputAllowed, err, "Expected runtime errors to be passed through")
+
+	_, err = call(`{"fail": "plain"}`)
+	assert.Equal(t, errInternalError, err, "Expected other errors to be hidden")
+
+	_, err = call(`{"fail": "panic"}`)
+	assert.Equal(t, errInternalError, err, "Expected panics to be recovered")
+}
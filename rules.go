@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+// Games a match can be created for with the "game" param.
+const (
+	gameXoxo        = "xoxo"
+	gameConnectFour = "connect4"
+)
+
+var (
+	errMoveOutOfRange = errors.New("move out of range")
+	errCellOccupied   = errors.New("cell occupied")
+)
+
+// gameRules are the rules of a two player turn-based game played by placing marks on a
+// board, which the match handler drives. Boards are cells numbered row by row from the
+// top left, so every game shares the Start, Update, Done and Move messages.
+type gameRules interface {
+	// newBoard returns the empty board a round starts with.
+	newBoard() []api.Mark
+	// legalMoves returns the moves which can be played on the board. The round is a
+	// draw when there is none left.
+	legalMoves(board []api.Mark) []int32
+	// apply plays the move for the mark and returns the cell the mark was placed in.
+	apply(board []api.Mark, move int32, mark api.Mark) (int32, error)
+	// winningLine returns the cells of the line the mark placed in cell completed, or
+	// nil when the mark didn't win.
+	winningLine(board []api.Mark, cell int32) []int32
+	// centerDistance returns a measure of how far from the center of the board the cell
+	// is, smaller being closer, which the AI uses to prefer central cells.
+	centerDistance(cell int32) int
+}
+
+// newGameRules returns the rules of the game selected by the "game" match param, xoxo
+// when it isn't set.
+func newGameRules(params map[string]interface{}) (gameRules, error) {
+	game, _ := params["game"].(string)
+	switch game {
+	case "", gameXoxo:
+		size := defaultBoardSize
+		if value, ok := params["size"]; ok {
+			size = intParam(value)
+		}
+		return newXoxoRules(size, intParam(params["win_length"]))
+	case gameConnectFour:
+		return newConnectFourRules(), nil
+	default:
+		return nil, fmt.Errorf("unknown game %q", game)
+	}
+}
+
+// lineBoard is a board of rows by columns cells won by the first player to get
+// winLength marks in a row, horizontally, vertically or diagonally.
+type lineBoard struct {
+	rows      int
+	columns   int
+	winLength int
+}
+
+// lineDirections are the row and column steps of the lines a round can be won along:
+// horizontal, vertical and both diagonals.
+var lineDirections = [][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
+
+// cells returns the number of cells on the board.
+func (b *lineBoard) cells() int {
+	return b.rows * b.columns
+}
+
+// winningLine returns the positions of the line through cell holding at least winLength
+// marks of the mark played there, or nil when there is none. Only lines through the last
+// move need checking, as the round ends as soon as a line is complete.
+func (b *lineBoard) winningLine(board []api.Mark, cell int32) []int32 {
+	mark := board[cell]
+	if mark == api.Mark_MARK_UNSPECIFIED {
+		return nil
+	}
+	row, column := int(cell)/b.columns, int(cell)%b.columns
+	for _, direction := range lineDirections {
+		// Walk back to the first mark of the line, then forward collecting it.
+		startRow, startColumn := row, column
+		for b.holds(board, startRow-direction[0], startColumn-direction[1], mark) {
+			startRow, startColumn = startRow-direction[0], startColumn-direction[1]
+		}
+		var line []int32
+		for i, j := startRow, startColumn; b.holds(board, i, j, mark); i, j = i+direction[0], j+direction[1] {
+			line = append(line, int32(i*b.columns+j))
+		}
+		if len(line) >= b.winLength {
+			return line
+		}
+	}
+	return nil
+}
+
+// holds reports whether the cell at row and column is on the board and holds the mark.
+func (b *lineBoard) holds(board []api.Mark, row, column int, mark api.Mark) bool {
+	return row >= 0 && row < b.rows && column >= 0 && column < b.columns && board[row*b.columns+column] == mark
+}
+
+func (b *lineBoard) centerDistance(cell int32) int {
+	// Doubled coordinates keep the center exact on boards of even size.
+	row, column := 2*(int(cell)/b.columns)-(b.rows-1), 2*(int(cell)%b.columns)-(b.columns-1)
+	return row*row + column*column
+}
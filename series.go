@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+const (
+	// defaultBestOf is the number of rounds of a series unless the match is created with
+	// the "best_of" param, every round being a series of its own.
+	defaultBestOf = 1
+	// maxBestOf is the longest series a match can be created with.
+	maxBestOf = 9
+)
+
+// validateBestOf checks the number of rounds of a series is odd, so a series can't end tied
+// on wins unless rounds are drawn.
+func validateBestOf(bestOf int) error {
+	if bestOf < 1 || bestOf > maxBestOf || bestOf%2 == 0 {
+		return fmt.Errorf("invalid best_of %d, expected an odd number from 1 to %d", bestOf, maxBestOf)
+	}
+	return nil
+}
+
+// samePlayers reports whether the players seated are the ones who played the last round.
+func (s *MatchState) samePlayers(userIDs []string) bool {
+	if len(s.marks) != len(userIDs) {
+		return false
+	}
+	for _, userID := range userIDs {
+		if _, ok := s.marks[userID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// swapMarks gives each player the mark of their opponent in the last round.
+func (s *MatchState) swapMarks() {
+	marks := make(map[string]api.Mark, len(s.marks))
+	for userID, mark := range s.marks {
+		marks[userID] = opponentMark(mark)
+	}
+	s.marks = marks
+}
+
+// newSeries resets the scores for the players seated.
+func (s *MatchState) newSeries() {
+	s.seriesRound = 0
+	s.seriesDone = false
+	s.scores = make(map[string]int32, len(s.marks))
+	for userID := range s.marks {
+		s.scores[userID] = 0
+	}
+}
+
+// scoreRound counts the round just finished in the series and returns the user ID of the
+// series winner once the series is done. The series is done as soon as a player won a
+// majority of its rounds, or after its last round with the player who won the most
+// rounds as the winner, if any.
+func (s *MatchState) scoreRound() string {
+	for userID, mark := range s.marks {
+		if mark == s.winner && s.winner != api.Mark_MARK_UNSPECIFIED {
+			s.scores[userID]++
+		}
+	}
+
+	leader, leaderScore, tied := "", int32(-1), false
+	for userID, score := range s.scores {
+		switch {
+		case score > leaderScore:
+			leader, leaderScore, tied = userID, score, false
+		case score == leaderScore:
+			tied = true
+		}
+	}
+	if int(leaderScore) > s.bestOf/2 {
+		s.seriesDone = true
+		return leader
+	}
+	if s.seriesRound >= s.bestOf {
+		s.seriesDone = true
+		if !tied {
+			return leader
+		}
+	}
+	return ""
+}
+
+// copyScores returns a copy of the scores for a message, which is encoded after the
+// scores may have changed.
+func (s *MatchState) copyScores() map[string]int32 {
+	scores := make(map[string]int32, len(s.scores))
+	for userID, score := range s.scores {
+		scores[userID] = score
+	}
+	return scores
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+// winRound makes the player of X win the round in progress.
+func (m *testMatch) winRound() {
+	x, o := m.players()
+	m.loop(m.move(x, 0), m.move(o, 3), m.move(x, 1), m.move(o, 4), m.move(x, 2))
+}
+
+// nextRound waits for the pause between rounds to pass.
+func (m *testMatch) nextRound() {
+	for i := 0; i <= delayBetweenGamesSec*tickRate; i++ {
+		m.loop()
+	}
+}
+
+func (m *testMatch) lastDone() *api.Done {
+	done := &api.Done{}
+	if err := proto.Unmarshal(m.dispatcher.last(api.OpCode_OPCODE_DONE).data, done); err != nil {
+		m.t.Fatalf("Failed to unmarshal done: %v", err)
+	}
+	return done
+}
+
+func TestMatchHandlerSeries(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, map[string]interface{}{"best_of": 3})
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	first, second := m.players()
+
+	m.winRound()
+	done := m.lastDone()
+	assert.False(t, done.SeriesDone)
+	assert.Equal(t, map[string]int32{first: 1, second: 0}, done.Scores)
+
+	m.nextRound()
+	x, _ := m.players()
+	assert.Equal(t, second, x, "Expected the marks to be swapped")
+	start := &api.Start{}
+	assert.NoError(t, proto.Unmarshal(m.dispatcher.last(api.OpCode_OPCODE_START).data, start))
+	assert.Equal(t, int32(2), start.Round)
+	assert.Equal(t, int32(3), start.BestOf)
+	assert.Equal(t, map[string]int32{first: 1, second: 0}, start.Scores)
+
+	m.winRound()
+	assert.False(t, m.lastDone().SeriesDone)
+	m.nextRound()
+	m.winRound()
+	done = m.lastDone()
+	assert.True(t, done.SeriesDone)
+	assert.Equal(t, first, done.SeriesWinner)
+	assert.Equal(t, map[string]int32{first: 2, second: 1}, done.Scores)
+
+	m.nextRound()
+	start = &api.Start{}
+	assert.NoError(t, proto.Unmarshal(m.dispatcher.last(api.OpCode_OPCODE_START).data, start))
+	assert.Equal(t, int32(1), start.Round, "Expected a new series to start")
+	assert.Equal(t, map[string]int32{first: 0, second: 0}, start.Scores)
+}
+
+func TestMatchHandlerSeriesDraws(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, map[string]interface{}{"best_of": 1})
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+	m.loop(m.move(x, 0), m.move(o, 1), m.move(x, 2), m.move(o, 4), m.move(x, 3), m.move(o, 5), m.move(x, 7), m.move(o, 6), m.move(x, 8))
+
+	done := m.lastDone()
+	assert.Equal(t, api.Mark_MARK_UNSPECIFIED, done.Winner)
+	assert.True(t, done.SeriesDone, "Expected the series to end after its last round")
+	assert.Empty(t, done.SeriesWinner)
+
+	for _, bestOf := range []int{0, 2, 11} {
+		assert.Error(t, validateBestOf(bestOf))
+	}
+}
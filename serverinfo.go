@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
+	"time"
 
 	"crypto/sha256"
 
@@ -15,53 +17,71 @@ import (
 
 // Payload represents the payload structure.
 type Payload struct {
-	Type    string `json:"type"`
-	Version string `json:"version"`
-	Hash    string `json:"hash"`
+	Type     string `json:"type"`
+	Version  string `json:"version"`
+	Hash     string `json:"hash"`
+	Platform string `json:"platform"`
+	Locale   string `json:"locale"`
+	Channel  string `json:"channel"`
 }
 
 // Response represents the response structure.
 type Response struct {
 	Type    string `json:"type"`
 	Version string `json:"version"`
+	Variant string `json:"variant"`
 	Hash    string `json:"hash"`
 	Content string `json:"content"`
 }
 
-const collectionName string = "ZeptoLabVersionChecker"
+// validate rejects type and version values which would escape the manifest directory.
+func (p *Payload) validate() error {
+	for _, field := range []struct{ name, value string }{
+		{"type", p.Type},
+		{"version", p.Version},
+	} {
+		if strings.HasPrefix(field.value, ".") || strings.ContainsAny(field.value, `/\`) {
+			return fmt.Errorf("invalid %s: %q", field.name, field.value)
+		}
+	}
+	_, err := manifestVariants(*p)
+	return err
+}
 
-// RPC function to process payload with optional parameters.
-func VersionChecker(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+// VersionChecker is the RPC function to process payload with optional parameters.
+var VersionChecker = newRpc("VersionChecker", versionChecker)
 
-	// Parse payload JSON.
-	var p Payload
-	if err := json.Unmarshal([]byte(payload), &p); err != nil {
-		logger.Error("problem with unmarshal: %s", err)
-		return "", err
-	}
+func versionChecker(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, p *Payload) (*Response, error) {
+	config := currentConfig()
 
 	// Set default values if not provided.
 	if p.Type == "" {
-		p.Type = "core"
+		p.Type = config.DefaultType
 	}
 	if p.Version == "" {
-		p.Version = "1.0.0"
+		p.Version = config.DefaultVersion
 	}
 
-	// Construct file path.
-	filePath := filepath.Join(p.Type, p.Version+".json")
-
-	// Check if file exists.
-	if _, err := os.Stat(filePath); err != nil {
-		logger.Error("file not found: %s", err)
-		return "", fmt.Errorf("file not found: %s", err)
+	// Resolve the most specific manifest variant that exists.
+	filePath, variant, err := resolveManifest(config.ManifestRoot, *p)
+	if err != nil {
+		logger.Error("%s", err)
+		if errors.Is(err, os.ErrNotExist) {
+			recordVersionCheck(nk, *p, false, outcomeNotFound)
+			return nil, runtime.NewError(err.Error(), 5) // NOT_FOUND
+		}
+		recordVersionCheck(nk, *p, false, outcomeError)
+		return nil, err
 	}
 
-	// Read file content.
-	content, err := os.ReadFile(filePath)
+	// Read file content with its inheritance chain resolved.
+	readStart := time.Now()
+	content, err := readManifest(config.ManifestRoot, filePath)
+	recordLatency(nk, metricManifestRead, *p, readStart)
+	recordManifestCache(nk)
 	if err != nil {
-		logger.Error("failed to read file: %s", err)
-		return "", fmt.Errorf("failed to read file: %s", err)
+		recordVersionCheck(nk, *p, true, outcomeError)
+		return nil, err
 	}
 
 	// Calculate content hash.
@@ -69,38 +89,43 @@ func VersionChecker(ctx context.Context, logger runtime.Logger, db *sql.DB, nk r
 	logger.Info("File hash is: %s", hash)
 
 	// Construct response.
-	response := Response{
+	response := &Response{
 		Type:    p.Type,
 		Version: p.Version,
+		Variant: variant,
 		Hash:    hash,
 		Content: string(content),
 	}
 	logger.Info("responce: %s", response)
 	// If hashes are not equal, set content to null.
 	// c746686a45ad8d1a06fad5502596466e9de877217a9a32f2253c542a71ee10e2
+	outcome := outcomeHit
 	if p.Hash == "" || p.Hash != hash {
 		response.Content = ""
+		outcome = outcomeMismatch
 	}
 
 	// Convert response to JSON string.
 	responseJSON, err := json.Marshal(response)
 	if err != nil {
-		logger.Error("failed to marshal response: %s", err)
-		return "", fmt.Errorf("failed to marshal response: %s", err)
+		recordVersionCheck(nk, *p, true, outcomeError)
+		return nil, fmt.Errorf("failed to marshal response: %s", err)
 	}
-	responseJSONString := string(responseJSON)
-	saveToDB(ctx, logger, nk, p, responseJSONString)
+	writeStart := time.Now()
+	saveToDB(ctx, logger, nk, config, *p, string(responseJSON))
+	recordUserCheck(ctx, logger, nk, *p, variant)
+	recordLatency(nk, metricStorageWrite, *p, writeStart)
+	recordVersionCheck(nk, *p, true, outcome)
 
-	return string(responseJSON), nil
+	return response, nil
 }
 
-func saveToDB(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, p Payload, response string) {
-	userID := "00000000-0000-0000-0000-000000000000"
+func saveToDB(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, config *ModuleConfig, p Payload, response string) {
 	key := fmt.Sprintf("%s/%s", p.Type, p.Version)
 	objectIDs := []*runtime.StorageWrite{&runtime.StorageWrite{
-		Collection: collectionName,
+		Collection: config.CollectionName,
 		Key:        key,
-		UserID:     userID,
+		UserID:     config.SystemUserID,
 		Value:      string(response),
 	},
 	}
@@ -108,6 +133,6 @@ func saveToDB(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModul
 	if err != nil {
 		logger.WithField("err", err).Error("Storage write error.")
 	} else {
-		logger.Info("Write data to storage successfully: [Collection: %s, Key:%s, Value: %s]", collectionName, key, response)
+		logger.Info("Write data to storage successfully: [Collection: %s, Key:%s, Value: %s]", config.CollectionName, key, response)
 	}
 }
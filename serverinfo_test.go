@@ -36,7 +36,7 @@ func TestProcessPayload(t *testing.T) {
 		t.Fatalf("Failed to write test file: %v", err)
 	}
 	if _, err := os.Stat(testFilePath); err != nil {
-		fmt.Errorf("test file not found: %s", err)
+		t.Errorf("test file not found: %s", err)
 	}
 	// Calculate content hash.
 	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(`{"content": "test content"}`)))
@@ -103,17 +103,19 @@ func (l *testLogger) Fields() map[string]interface{} {
 
 // Warn implements runtime.Logger.
 func (l *testLogger) Warn(format string, v ...interface{}) {
-	panic("unimplemented")
+	fmt.Printf(format+"\n", v...)
 }
 
 // WithField implements runtime.Logger.
 func (l *testLogger) WithField(key string, v interface{}) runtime.Logger {
-	panic("unimplemented")
+	// Fields are not printed in tests.
+	return l
 }
 
 // WithFields implements runtime.Logger.
 func (l *testLogger) WithFields(fields map[string]interface{}) runtime.Logger {
-	panic("unimplemented")
+	// Fields are not printed in tests.
+	return l
 }
 
 func (l *testLogger) Info(format string, args ...interface{}) {
@@ -353,7 +355,7 @@ func (t *testNakamaModule) LeaderboardRecordDelete(ctx context.Context, id strin
 
 // LeaderboardRecordWrite implements runtime.NakamaModule.
 func (t *testNakamaModule) LeaderboardRecordWrite(ctx context.Context, id string, ownerID string, username string, score int64, subscore int64, metadata map[string]interface{}, overrideOperator *int) (*api.LeaderboardRecord, error) {
-	panic("unimplemented")
+	return nil, nil
 }
 
 // LeaderboardRecordsHaystack implements runtime.NakamaModule.
@@ -438,17 +440,17 @@ func (t *testNakamaModule) MatchSignal(ctx context.Context, id string, data stri
 
 // MetricsCounterAdd implements runtime.NakamaModule.
 func (t *testNakamaModule) MetricsCounterAdd(name string, tags map[string]string, delta int64) {
-	panic("unimplemented")
+	// Metrics are not collected in tests.
 }
 
 // MetricsGaugeSet implements runtime.NakamaModule.
 func (t *testNakamaModule) MetricsGaugeSet(name string, tags map[string]string, value float64) {
-	panic("unimplemented")
+	// Metrics are not collected in tests.
 }
 
 // MetricsTimerRecord implements runtime.NakamaModule.
 func (t *testNakamaModule) MetricsTimerRecord(name string, tags map[string]string, value time.Duration) {
-	panic("unimplemented")
+	// Metrics are not collected in tests.
 }
 
 // MultiUpdate implements runtime.NakamaModule.
@@ -531,7 +533,7 @@ func (t *testNakamaModule) StorageList(ctx context.Context, userID string, colle
 
 // StorageRead implements runtime.NakamaModule.
 func (t *testNakamaModule) StorageRead(ctx context.Context, reads []*runtime.StorageRead) ([]*api.StorageObject, error) {
-	panic("unimplemented")
+	return nil, nil
 }
 
 // StorageWrite implements runtime.NakamaModule.
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+const (
+	// statsCollectionName holds the stats of each player under statsKey.
+	statsCollectionName = "xoxo_stats"
+	statsKey            = "stats"
+
+	// maxStatsUsers is the number of users whose stats can be fetched at once.
+	maxStatsUsers = 100
+	// statsWriteAttempts is how many times a stats update is tried when the stats change
+	// concurrently.
+	statsWriteAttempts = 3
+)
+
+// PlayerStats are the stats of a player aggregated over every round they finished.
+type PlayerStats struct {
+	Games  int `json:"games"`
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+	// WinStreak is the number of rounds won in a row up to the last one, BestWinStreak the
+	// longest such run.
+	WinStreak     int `json:"win_streak"`
+	BestWinStreak int `json:"best_win_streak"`
+	WinsVsAI      int `json:"wins_vs_ai"`
+	WinsVsHumans  int `json:"wins_vs_humans"`
+	FastGames     int `json:"fast_games"`
+	FastWins      int `json:"fast_wins"`
+	NormalGames   int `json:"normal_games"`
+	NormalWins    int `json:"normal_wins"`
+	// Moves and MoveTimeMs are the number and the total time in milliseconds of the moves
+	// played in answer to an opponent's move. The first move of a round isn't timed.
+	Moves         int   `json:"moves"`
+	MoveTimeMs    int64 `json:"move_time_ms"`
+	AvgMoveTimeMs int64 `json:"avg_move_time_ms"`
+}
+
+// StatsRequest represents the payload of the get_stats RPC. The caller's stats are
+// returned when UserIDs is empty.
+type StatsRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// StatsResponse represents the response of the get_stats RPC, the stats by user ID.
+// Users who haven't finished a round get empty stats.
+type StatsResponse struct {
+	Stats map[string]*PlayerStats `json:"stats"`
+}
+
+func (r *StatsRequest) validate() error {
+	if len(r.UserIDs) > maxStatsUsers {
+		return fmt.Errorf("at most %d user_ids are allowed", maxStatsUsers)
+	}
+	for _, userID := range r.UserIDs {
+		if !uuidPattern.MatchString(userID) {
+			return fmt.Errorf("user_id %q is not a valid UUID", userID)
+		}
+	}
+	return nil
+}
+
+// recordStats adds the finished rounds to the stats of their human players. Failures
+// are logged so they don't hold up the match.
+func recordStats(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, replays []*Replay) {
+	for _, replay := range replays {
+		if err := updateStats(ctx, nk, replay); err != nil {
+			logger.Error("failed to update stats of round %d: %s", replay.Round, err)
+		}
+	}
+}
+
+// statsObjects are the stored stats, players without any start with empty stats.
+var statsObjects = &userObjects[PlayerStats]{
+	collection: statsCollectionName,
+	key:        statsKey,
+	attempts:   statsWriteAttempts,
+	newValue:   func() *PlayerStats { return &PlayerStats{} },
+}
+
+// updateStats adds a round to the stats of its human players. The stats of all of them
+// are written only if none changed since they were read, which is retried a few times.
+func updateStats(ctx context.Context, nk runtime.NakamaModule, replay *Replay) error {
+	userIDs := make([]string, 0, len(replay.Marks))
+	for userID := range replay.Marks {
+		if userID != aiUserID {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	_, err := statsObjects.update(ctx, nk, func(stats map[string]*PlayerStats) {
+		for _, userID := range userIDs {
+			stats[userID].addRound(replay, userID)
+		}
+	}, userIDs...)
+	return err
+}
+
+// addRound adds the result and the move times of the user in a finished round.
+func (p *PlayerStats) addRound(replay *Replay, userID string) {
+	mark := replay.Marks[userID]
+	_, vsAI := replay.Marks[aiUserID]
+
+	p.Games++
+	if replay.Fast {
+		p.FastGames++
+	} else {
+		p.NormalGames++
+	}
+	switch replay.Winner {
+	case mark:
+		p.Wins++
+		p.WinStreak++
+		if p.WinStreak > p.BestWinStreak {
+			p.BestWinStreak = p.WinStreak
+		}
+		if vsAI {
+			p.WinsVsAI++
+		} else {
+			p.WinsVsHumans++
+		}
+		if replay.Fast {
+			p.FastWins++
+		} else {
+			p.NormalWins++
+		}
+	case api.Mark_MARK_UNSPECIFIED:
+		p.Draws++
+		p.WinStreak = 0
+	default:
+		p.Losses++
+		p.WinStreak = 0
+	}
+
+	for i := 1; i < len(replay.Moves); i++ {
+		if replay.Moves[i].Mark != mark {
+			continue
+		}
+		p.Moves++
+		p.MoveTimeMs += replay.Moves[i].Timestamp - replay.Moves[i-1].Timestamp
+	}
+	if p.Moves > 0 {
+		p.AvgMoveTimeMs = p.MoveTimeMs / int64(p.Moves)
+	}
+}
+
+// GetStats returns the stats of the caller, or of the listed users for profile screens.
+var GetStats = newRpc("get_stats", getStats)
+
+func getStats(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, request *StatsRequest) (*StatsResponse, error) {
+	userIDs := request.UserIDs
+	if len(userIDs) == 0 {
+		if userID := contextUserID(ctx); userID != "" {
+			userIDs = []string{userID}
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil, runtime.NewError("user_ids is required", 3) // INVALID_ARGUMENT
+	}
+
+	stats, _, err := statsObjects.read(ctx, nk, userIDs...)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsResponse{Stats: stats}, nil
+}
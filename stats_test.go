@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+func TestPlayerStatsAddRound(t *testing.T) {
+	t.Parallel()
+	stats := &PlayerStats{}
+	replay := &Replay{
+		Fast:   true,
+		Marks:  map[string]api.Mark{"user1": api.Mark_MARK_X, aiUserID: api.Mark_MARK_O},
+		Winner: api.Mark_MARK_X,
+		Moves: []*ReplayMove{
+			{Mark: api.Mark_MARK_X, Timestamp: 1000},
+			{Mark: api.Mark_MARK_O, Timestamp: 1000},
+			{Mark: api.Mark_MARK_X, Timestamp: 3000},
+			{Mark: api.Mark_MARK_O, Timestamp: 3000},
+			{Mark: api.Mark_MARK_X, Timestamp: 7000},
+		},
+	}
+	stats.addRound(replay, "user1")
+	stats.addRound(replay, "user1")
+	assert.Equal(t, 2, stats.Wins)
+	assert.Equal(t, 2, stats.WinStreak)
+	assert.Equal(t, 2, stats.WinsVsAI)
+	assert.Equal(t, 2, stats.FastWins)
+	assert.Equal(t, 4, stats.Moves, "Expected the first move not to be timed")
+	assert.Equal(t, int64(3000), stats.AvgMoveTimeMs)
+
+	replay.Fast = false
+	replay.Winner = api.Mark_MARK_O
+	stats.addRound(replay, "user1")
+	assert.Equal(t, 3, stats.Games)
+	assert.Equal(t, 1, stats.Losses)
+	assert.Equal(t, 0, stats.WinStreak)
+	assert.Equal(t, 2, stats.BestWinStreak)
+	assert.Equal(t, 1, stats.NormalGames)
+	assert.Equal(t, 0, stats.NormalWins)
+}
+
+func TestGetStats(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	nk := newStorageTestNakamaModule()
+	m := newTestMatch(t, nil)
+	m.nk = nk
+	m.join("6d1b3a52-0c4e-4c1f-9a51-3f0f2b9f7c01", nil)
+	m.join("6d1b3a52-0c4e-4c1f-9a51-3f0f2b9f7c02", nil)
+	m.loop()
+	m.winRound()
+	winner, loser := m.players()
+
+	payload, _ := json.Marshal(&StatsRequest{UserIDs: []string{winner, loser, "6d1b3a52-0c4e-4c1f-9a51-3f0f2b9f7c03"}})
+	result, err := GetStats(ctx, &testLogger{}, nil, nk, string(payload))
+	if !assert.NoError(t, err) {
+		return
+	}
+	response := &StatsResponse{}
+	assert.NoError(t, json.Unmarshal([]byte(result), response))
+	assert.Equal(t, 1, response.Stats[winner].Wins)
+	assert.Equal(t, 1, response.Stats[winner].WinsVsHumans)
+	assert.Equal(t, 1, response.Stats[winner].NormalWins)
+	assert.Equal(t, 1, response.Stats[loser].Losses)
+	assert.Equal(t, 0, response.Stats["6d1b3a52-0c4e-4c1f-9a51-3f0f2b9f7c03"].Games, "Expected empty stats for users without rounds")
+
+	userCtx := context.WithValue(ctx, runtime.RUNTIME_CTX_USER_ID, loser)
+	result, err = GetStats(userCtx, &testLogger{}, nil, nk, "")
+	if assert.NoError(t, err) {
+		response = &StatsResponse{}
+		assert.NoError(t, json.Unmarshal([]byte(result), response))
+		assert.Len(t, response.Stats, 1)
+		assert.Equal(t, 1, response.Stats[loser].Games)
+	}
+
+	_, err = GetStats(ctx, &testLogger{}, nil, nk, "")
+	assert.Equal(t, 3, rpcErrorCode(err), "Expected a user ID to be required for server calls")
+
+	payload, _ = json.Marshal(&StatsRequest{UserIDs: []string{winner, "user3"}})
+	_, err = GetStats(ctx, &testLogger{}, nil, nk, string(payload))
+	assert.Equal(t, 3, rpcErrorCode(err), "Expected user IDs which aren't UUIDs to be rejected")
+
+	userIDs := make([]string, maxStatsUsers+1)
+	for i := range userIDs {
+		userIDs[i] = "user" + strconv.Itoa(i)
+	}
+	payload, _ = json.Marshal(&StatsRequest{UserIDs: userIDs})
+	_, err = GetStats(ctx, &testLogger{}, nil, nk, string(payload))
+	assert.Equal(t, 3, rpcErrorCode(err), "Expected too many user IDs to be rejected")
+}
+
+func TestUpdateStatsConflict(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	nk := &conflictingNakamaModule{storageTestNakamaModule: newStorageTestNakamaModule()}
+	replay := &Replay{Marks: map[string]api.Mark{"user1": api.Mark_MARK_X, "user2": api.Mark_MARK_O}, Winner: api.Mark_MARK_X}
+	assert.NoError(t, updateStats(ctx, nk, replay))
+	assert.NoError(t, updateStats(ctx, nk, replay), "Expected the update to be retried")
+	assert.Equal(t, 3, nk.reads)
+
+	stats, _, err := statsObjects.read(ctx, nk, "user1", "user2")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, stats["user1"].Wins, "Expected the stats to be updated once per round")
+		assert.Equal(t, 2, stats["user2"].Losses)
+	}
+}
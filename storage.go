@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// userObjects describes an object stored under the same collection and key for every
+// user, owned by and readable by the user. Only the server writes it.
+type userObjects[T any] struct {
+	collection string
+	key        string
+	// attempts is how many times an update is tried when the objects change concurrently.
+	attempts int
+	// newValue returns the value of users without a stored object.
+	newValue func() *T
+}
+
+// read returns the objects of the users and the versions to write them back with.
+// Users without a stored object get a new value and the version "*", which only lets
+// the write through if no object was stored meanwhile.
+func (u *userObjects[T]) read(ctx context.Context, nk runtime.NakamaModule, userIDs ...string) (map[string]*T, map[string]string, error) {
+	reads := make([]*runtime.StorageRead, 0, len(userIDs))
+	values := make(map[string]*T, len(userIDs))
+	versions := make(map[string]string, len(userIDs))
+	for _, userID := range userIDs {
+		reads = append(reads, &runtime.StorageRead{Collection: u.collection, Key: u.key, UserID: userID})
+		values[userID] = u.newValue()
+		versions[userID] = "*"
+	}
+
+	objects, err := nk.StorageRead(ctx, reads)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %s", u.collection, err)
+	}
+	for _, object := range objects {
+		value := new(T)
+		if err := json.Unmarshal([]byte(object.Value), value); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal %s of %s: %s", u.collection, object.UserId, err)
+		}
+		values[object.UserId] = value
+		versions[object.UserId] = object.Version
+	}
+	return values, versions, nil
+}
+
+// update reads the objects of the users, lets modify change them and writes them back.
+// They are written only if none changed since they were read, otherwise they're read
+// and modified again, up to the number of attempts. The written values are returned.
+func (u *userObjects[T]) update(ctx context.Context, nk runtime.NakamaModule, modify func(values map[string]*T), userIDs ...string) (map[string]*T, error) {
+	var err error
+	for attempt := 0; attempt < u.attempts; attempt++ {
+		var values map[string]*T
+		var versions map[string]string
+		if values, versions, err = u.read(ctx, nk, userIDs...); err != nil {
+			return nil, err
+		}
+		modify(values)
+
+		writes := make([]*runtime.StorageWrite, 0, len(userIDs))
+		for _, userID := range userIDs {
+			value, err := json.Marshal(values[userID])
+			if err != nil {
+				return nil, err
+			}
+			writes = append(writes, &runtime.StorageWrite{
+				Collection:      u.collection,
+				Key:             u.key,
+				UserID:          userID,
+				Value:           string(value),
+				Version:         versions[userID],
+				PermissionRead:  1, // Owner read.
+				PermissionWrite: 0, // No client write.
+			})
+		}
+		if _, err = nk.StorageWrite(ctx, writes); err != nil {
+			// The write fails as a whole when a version doesn't match, read again and retry.
+			continue
+		}
+		return values, nil
+	}
+	return nil, err
+}
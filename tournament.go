@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+const (
+	// notificationCodeTournamentReward is the notification code of tournament rewards.
+	notificationCodeTournamentReward = 1
+	// tournamentRecordsPage is the number of records listed at once when rewarding.
+	tournamentRecordsPage = 100
+)
+
+// TournamentsManifest is the manifest defining the recurring tournaments. Being a
+// manifest, clients can read it with the VersionChecker RPC to show the prizes.
+type TournamentsManifest struct {
+	Tournaments []*TournamentConfig `json:"tournaments"`
+}
+
+// TournamentConfig defines a recurring tournament: the arguments of TournamentCreate
+// and the rewards handed out when it ends.
+type TournamentConfig struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Category    int    `json:"category"`
+	SortOrder   string `json:"sort_order"`
+	Operator    string `json:"operator"`
+	// ResetSchedule is the cron expression the tournament restarts on, e.g. "0 0 * * 1"
+	// for every Monday.
+	ResetSchedule string `json:"reset_schedule"`
+	// Duration is how long the tournament is open for in seconds after each reset.
+	Duration     int                 `json:"duration"`
+	MaxSize      int                 `json:"max_size"`
+	MaxNumScore  int                 `json:"max_num_score"`
+	JoinRequired bool                `json:"join_required"`
+	Rewards      []*TournamentReward `json:"rewards"`
+}
+
+// TournamentReward is the wallet changeset given to the players ranked from MinRank
+// to MaxRank, both inclusive.
+type TournamentReward struct {
+	MinRank int64            `json:"min_rank"`
+	MaxRank int64            `json:"max_rank"`
+	Wallet  map[string]int64 `json:"wallet"`
+}
+
+// loadTournaments reads the tournaments manifest set in the config, nil when tournaments
+// are off. Tournaments without a sort order or operator rank by descending total wins.
+func loadTournaments(config *ModuleConfig) ([]*TournamentConfig, error) {
+	if config.TournamentsManifest == "" {
+		return nil, nil
+	}
+	p, err := config.tournamentsPayload()
+	if err != nil {
+		return nil, err
+	}
+	content, err := readManifest(config.ManifestRoot, filepath.Join(config.ManifestRoot, manifestPath(p.Type, p.Version, "")))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &TournamentsManifest{}
+	if err := json.Unmarshal(content, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse tournaments manifest: %s", err)
+	}
+	for _, tournament := range manifest.Tournaments {
+		if err := tournament.validate(); err != nil {
+			return nil, err
+		}
+		if tournament.SortOrder == "" {
+			tournament.SortOrder = "desc"
+		}
+		if tournament.Operator == "" {
+			tournament.Operator = "incr"
+		}
+	}
+	return manifest.Tournaments, nil
+}
+
+func (t *TournamentConfig) validate() error {
+	if t.ID == "" {
+		return errors.New("tournament id is required")
+	}
+	if t.ResetSchedule == "" || t.Duration <= 0 {
+		return fmt.Errorf("tournament %s needs a reset_schedule and a positive duration", t.ID)
+	}
+	for _, reward := range t.Rewards {
+		if reward.MinRank < 1 || reward.MaxRank < reward.MinRank {
+			return fmt.Errorf("tournament %s has an invalid reward rank range %d-%d", t.ID, reward.MinRank, reward.MaxRank)
+		}
+	}
+	return nil
+}
+
+// reward returns the reward of a rank, nil if the rank gets none.
+func (t *TournamentConfig) reward(rank int64) *TournamentReward {
+	for _, reward := range t.Rewards {
+		if rank >= reward.MinRank && rank <= reward.MaxRank {
+			return reward
+		}
+	}
+	return nil
+}
+
+// maxRewardedRank returns the lowest rank which gets a reward.
+func (t *TournamentConfig) maxRewardedRank() int64 {
+	var maxRank int64
+	for _, reward := range t.Rewards {
+		if reward.MaxRank > maxRank {
+			maxRank = reward.MaxRank
+		}
+	}
+	return maxRank
+}
+
+// createTournaments creates the tournaments of the manifest, those which already exist
+// are left as they are.
+func createTournaments(ctx context.Context, nk runtime.NakamaModule, tournaments []*TournamentConfig) error {
+	for _, t := range tournaments {
+		if err := nk.TournamentCreate(ctx, t.ID, true, t.SortOrder, t.Operator, t.ResetSchedule, map[string]interface{}{},
+			t.Title, t.Description, t.Category, 0, 0, t.Duration, t.MaxSize, t.MaxNumScore, t.JoinRequired); err != nil {
+			return fmt.Errorf("failed to create tournament %s: %s", t.ID, err)
+		}
+	}
+	return nil
+}
+
+// recordTournamentResults adds the round wins of human players to the tournaments. Rounds
+// against the AI, wins outside of a tournament's duration and wins of players who didn't
+// join a tournament requiring it don't count.
+func recordTournamentResults(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, tournaments []*TournamentConfig, replays []*Replay) {
+	for _, replay := range replays {
+		if _, ok := replay.Marks[aiUserID]; ok {
+			continue
+		}
+		for userID, mark := range replay.Marks {
+			if mark != replay.Winner {
+				continue
+			}
+			for _, t := range tournaments {
+				_, err := nk.TournamentRecordWrite(ctx, t.ID, userID, "", 1, 0, nil, nil)
+				switch {
+				case errors.Is(err, runtime.ErrTournamentOutsideDuration), errors.Is(err, runtime.ErrTournamentWriteJoinRequired):
+					logger.Debug("win of %s not recorded in tournament %s: %s", userID, t.ID, err)
+				case err != nil:
+					logger.Error("failed to record win of %s in tournament %s: %s", userID, t.ID, err)
+				}
+			}
+		}
+	}
+}
+
+// TournamentEnd hands out the rewards of a tournament defined in the tournaments manifest
+// when it ends. The rewards are those of the config in use at that time, so they can be
+// changed by reloading the config without a restart.
+func TournamentEnd(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, tournament *api.Tournament, end, reset int64) error {
+	for _, t := range currentConfig().tournaments {
+		if t.ID == tournament.GetId() {
+			return distributeRewards(ctx, logger, nk, t, end, reset)
+		}
+	}
+	return nil
+}
+
+// distributeRewards credits the wallets of the players ranked for a reward in the
+// tournament period which ended at end and notifies them. Nakama expires the records of
+// a period at the following reset, which is how they're listed.
+func distributeRewards(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, t *TournamentConfig, end, reset int64) error {
+	maxRank := t.maxRewardedRank()
+	var notifications []*runtime.NotificationSend
+	cursor := ""
+	for {
+		records, _, _, nextCursor, err := nk.TournamentRecordsList(ctx, t.ID, nil, tournamentRecordsPage, cursor, reset)
+		if err != nil {
+			return fmt.Errorf("failed to list records of tournament %s: %s", t.ID, err)
+		}
+		for _, record := range records {
+			if record.Rank > maxRank {
+				nextCursor = ""
+				break
+			}
+			reward := t.reward(record.Rank)
+			if reward == nil {
+				continue
+			}
+			metadata := map[string]interface{}{"tournament_id": t.ID, "rank": record.Rank, "end": end}
+			if _, _, err := nk.WalletUpdate(ctx, record.OwnerId, reward.Wallet, metadata, true); err != nil {
+				logger.Error("failed to reward %s for rank %d in tournament %s: %s", record.OwnerId, record.Rank, t.ID, err)
+				continue
+			}
+			notifications = append(notifications, &runtime.NotificationSend{
+				UserID:     record.OwnerId,
+				Subject:    fmt.Sprintf("%s reward", t.Title),
+				Content:    map[string]interface{}{"tournament_id": t.ID, "rank": record.Rank, "reward": reward.Wallet},
+				Code:       notificationCodeTournamentReward,
+				Persistent: true,
+			})
+		}
+		if nextCursor == "" {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := nk.NotificationsSend(ctx, notifications); err != nil {
+		logger.Error("failed to notify rewards of tournament %s: %s", t.ID, err)
+	}
+	logger.Info("rewarded %d players of tournament %s", len(notifications), t.ID)
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	nkapi "github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+// tournamentTestNakamaModule pages through ranked tournament records expiring at expiry
+// and keeps the wallet updates, notifications and tournament scores sent.
+type tournamentTestNakamaModule struct {
+	testNakamaModule
+	expiry        int64
+	records       []*nkapi.LeaderboardRecord
+	wallets       map[string]map[string]int64
+	notifications []*runtime.NotificationSend
+	scores        map[string]int64
+}
+
+func (t *tournamentTestNakamaModule) TournamentRecordWrite(ctx context.Context, id, ownerID, username string, score, subscore int64, metadata map[string]interface{}, operatorOverride *int) (*nkapi.LeaderboardRecord, error) {
+	if t.scores == nil {
+		t.scores = make(map[string]int64)
+	}
+	t.scores[ownerID] += score
+	return &nkapi.LeaderboardRecord{OwnerId: ownerID, Score: t.scores[ownerID]}, nil
+}
+
+func (t *tournamentTestNakamaModule) TournamentRecordsList(ctx context.Context, tournamentId string, ownerIDs []string, limit int, cursor string, overrideExpiry int64) (records []*nkapi.LeaderboardRecord, ownerRecords []*nkapi.LeaderboardRecord, prevCursor string, nextCursor string, err error) {
+	if overrideExpiry != t.expiry {
+		return nil, nil, "", "", nil
+	}
+	start, _ := strconv.Atoi(cursor)
+	end := start + limit
+	if end >= len(t.records) {
+		return t.records[start:], nil, "", "", nil
+	}
+	return t.records[start:end], nil, "", strconv.Itoa(end), nil
+}
+
+func (t *tournamentTestNakamaModule) WalletUpdate(ctx context.Context, userID string, changeset map[string]int64, metadata map[string]interface{}, updateLedger bool) (updated map[string]int64, previous map[string]int64, err error) {
+	if t.wallets == nil {
+		t.wallets = make(map[string]map[string]int64)
+	}
+	if t.wallets[userID] == nil {
+		t.wallets[userID] = make(map[string]int64)
+	}
+	for currency, amount := range changeset {
+		t.wallets[userID][currency] += amount
+	}
+	return t.wallets[userID], nil, nil
+}
+
+func (t *tournamentTestNakamaModule) NotificationsSend(ctx context.Context, notifications []*runtime.NotificationSend) error {
+	t.notifications = append(t.notifications, notifications...)
+	return nil
+}
+
+func TestLoadTournaments(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "tournaments"), 0755); err != nil {
+		t.Fatalf("Failed to create test dir: %v", err)
+	}
+	writeManifest := func(version, content string) {
+		if err := os.WriteFile(filepath.Join(root, "tournaments", version+".json"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	writeManifest("1.0.0", `{"tournaments": [{"id": "weekly", "reset_schedule": "0 0 * * 1", "duration": 604800, "rewards": [{"min_rank": 1, "max_rank": 3, "wallet": {"gems": 10}}]}]}`)
+	writeManifest("1.0.1", `{"tournaments": [{"id": "weekly", "reset_schedule": "0 0 * * 1", "duration": 604800, "rewards": [{"min_rank": 3, "max_rank": 1}]}]}`)
+
+	config, err := loadModuleConfig(map[string]string{"manifest_root": root, "tournaments_manifest": "tournaments/1.0.0"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	tournaments, err := loadTournaments(config)
+	if assert.NoError(t, err) && assert.Len(t, tournaments, 1) {
+		assert.Equal(t, "desc", tournaments[0].SortOrder)
+		assert.Equal(t, "incr", tournaments[0].Operator)
+		assert.Equal(t, int64(3), tournaments[0].maxRewardedRank())
+	}
+
+	assert.Equal(t, tournaments, config.tournaments, "Expected the tournaments to be loaded with the config")
+
+	config.TournamentsManifest = "tournaments/1.0.1"
+	_, err = loadTournaments(config)
+	assert.Error(t, err, "Expected an invalid rank range to be rejected")
+	_, err = loadModuleConfig(map[string]string{"manifest_root": root, "tournaments_manifest": "tournaments/1.0.1"})
+	assert.Error(t, err, "Expected a config with invalid tournaments to be rejected")
+
+	config.TournamentsManifest = ""
+	tournaments, err = loadTournaments(config)
+	assert.NoError(t, err)
+	assert.Empty(t, tournaments)
+
+	_, err = loadModuleConfig(map[string]string{"tournaments_manifest": "tournaments"})
+	assert.Error(t, err, "Expected a manifest without a version to be rejected")
+}
+
+func TestDistributeRewards(t *testing.T) {
+	t.Parallel()
+	nk := &tournamentTestNakamaModule{expiry: 2000}
+	for rank := int64(1); rank <= 250; rank++ {
+		nk.records = append(nk.records, &nkapi.LeaderboardRecord{OwnerId: "user" + strconv.FormatInt(rank, 10), Rank: rank})
+	}
+	tournament := &TournamentConfig{
+		ID:    "weekly",
+		Title: "Weekly",
+		Rewards: []*TournamentReward{
+			{MinRank: 1, MaxRank: 1, Wallet: map[string]int64{"gems": 100}},
+			{MinRank: 2, MaxRank: 3, Wallet: map[string]int64{"gems": 50}},
+			{MinRank: 101, MaxRank: 150, Wallet: map[string]int64{"coins": 10}},
+		},
+	}
+
+	assert.NoError(t, distributeRewards(context.Background(), &testLogger{}, nk, tournament, 1000, 2000))
+	assert.Equal(t, int64(100), nk.wallets["user1"]["gems"])
+	assert.Equal(t, int64(50), nk.wallets["user3"]["gems"])
+	assert.Equal(t, int64(10), nk.wallets["user150"]["coins"], "Expected rewards past the first page")
+	assert.NotContains(t, nk.wallets, "user4")
+	assert.NotContains(t, nk.wallets, "user151")
+	assert.Len(t, nk.wallets, 53)
+	if assert.Len(t, nk.notifications, 53) {
+		assert.Equal(t, "user1", nk.notifications[0].UserID)
+		assert.Equal(t, notificationCodeTournamentReward, nk.notifications[0].Code)
+		assert.True(t, nk.notifications[0].Persistent)
+	}
+}
+
+func TestRecordTournamentResults(t *testing.T) {
+	t.Parallel()
+	nk := &tournamentTestNakamaModule{}
+	tournaments := []*TournamentConfig{{ID: "weekly"}}
+	recordTournamentResults(context.Background(), &testLogger{}, nk, tournaments, []*Replay{
+		{Marks: map[string]api.Mark{"user1": api.Mark_MARK_X, "user2": api.Mark_MARK_O}, Winner: api.Mark_MARK_X},
+		{Marks: map[string]api.Mark{"user2": api.Mark_MARK_X, aiUserID: api.Mark_MARK_O}, Winner: api.Mark_MARK_X},
+	})
+	assert.Equal(t, map[string]int64{"user1": 1}, nk.scores, "Expected wins against the AI not to count")
+}
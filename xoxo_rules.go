@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+const (
+	// defaultBoardSize is the width and height of the board unless the match is created
+	// with the "size" param.
+	defaultBoardSize = 3
+	// maxBoardSize is the largest board a match can be created with, big enough for gomoku.
+	maxBoardSize = 19
+	// maxDefaultWinLength caps the default win length on large boards, five in a row as in gomoku.
+	maxDefaultWinLength = 5
+	// minWinLength is the shortest line which can win a round.
+	minWinLength = 3
+)
+
+// xoxoRules are the rules of xoxo: players place their mark on any free cell of a size
+// by size board, the first to get winLength marks in a row wins. A move is the cell played.
+type xoxoRules struct {
+	lineBoard
+}
+
+// newXoxoRules checks the board size and win length, a zero win length selecting the
+// default for the size.
+func newXoxoRules(size, winLength int) (*xoxoRules, error) {
+	if size < minWinLength || size > maxBoardSize {
+		return nil, fmt.Errorf("invalid board size %d, expected %d to %d", size, minWinLength, maxBoardSize)
+	}
+	if winLength == 0 {
+		winLength = size
+		if winLength > maxDefaultWinLength {
+			winLength = maxDefaultWinLength
+		}
+	}
+	if winLength < minWinLength || winLength > size {
+		return nil, fmt.Errorf("invalid win length %d, expected %d to %d", winLength, minWinLength, size)
+	}
+	return &xoxoRules{lineBoard: lineBoard{rows: size, columns: size, winLength: winLength}}, nil
+}
+
+func (r *xoxoRules) newBoard() []api.Mark {
+	return make([]api.Mark, r.cells())
+}
+
+func (r *xoxoRules) legalMoves(board []api.Mark) []int32 {
+	var moves []int32
+	for cell, mark := range board {
+		if mark == api.Mark_MARK_UNSPECIFIED {
+			moves = append(moves, int32(cell))
+		}
+	}
+	return moves
+}
+
+func (r *xoxoRules) apply(board []api.Mark, move int32, mark api.Mark) (int32, error) {
+	if move < 0 || move >= int32(len(board)) {
+		return 0, errMoveOutOfRange
+	}
+	if board[move] != api.Mark_MARK_UNSPECIFIED {
+		return 0, errCellOccupied
+	}
+	board[move] = mark
+	return move, nil
+}
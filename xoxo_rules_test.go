@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/heroiclabs/nakama-project-template/api"
+)
+
+func TestNewXoxoRules(t *testing.T) {
+	t.Parallel()
+	rules, err := newXoxoRules(15, 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 5, rules.winLength, "Expected gomoku boards to default to five in a row")
+		assert.Equal(t, 225, rules.cells())
+	}
+	rules, err = newXoxoRules(4, 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 4, rules.winLength)
+	}
+
+	for _, params := range [][2]int{{2, 0}, {20, 0}, {3, 4}, {5, 2}} {
+		_, err := newXoxoRules(params[0], params[1])
+		assert.Error(t, err, "Expected size %d and win length %d to be rejected", params[0], params[1])
+	}
+}
+
+func TestXoxoRulesWinningLine(t *testing.T) {
+	t.Parallel()
+	x, o := api.Mark_MARK_X, api.Mark_MARK_O
+	rules, _ := newXoxoRules(15, 5)
+
+	tests := []struct {
+		name      string
+		positions []int32
+		last      int32
+		expected  []int32
+	}{
+		{"horizontal", []int32{20, 21, 22, 23, 24}, 22, []int32{20, 21, 22, 23, 24}},
+		{"vertical", []int32{0, 15, 30, 45, 60}, 0, []int32{0, 15, 30, 45, 60}},
+		{"diagonal", []int32{16, 32, 48, 64, 80}, 80, []int32{16, 32, 48, 64, 80}},
+		{"anti-diagonal", []int32{14, 28, 42, 56, 70}, 42, []int32{14, 28, 42, 56, 70}},
+		{"overline", []int32{0, 1, 2, 3, 4, 5}, 5, []int32{0, 1, 2, 3, 4, 5}},
+		{"short", []int32{0, 1, 2, 3}, 3, nil},
+		{"wrapping", []int32{12, 13, 14, 15, 16}, 14, nil},
+	}
+	for _, test := range tests {
+		board := make([]api.Mark, rules.cells())
+		for _, position := range test.positions {
+			board[position] = x
+		}
+		assert.Equal(t, test.expected, rules.winningLine(board, test.last), test.name)
+	}
+
+	board := make([]api.Mark, rules.cells())
+	for _, position := range []int32{0, 1, 3, 4} {
+		board[position] = x
+	}
+	board[2] = o
+	assert.Nil(t, rules.winningLine(board, 4), "Expected a line broken by the opponent not to win")
+}
+
+func TestMatchHandlerBoardParams(t *testing.T) {
+	t.Parallel()
+	m := newTestMatch(t, map[string]interface{}{"size": 4.0})
+	assert.Equal(t, 4, m.matchState().label.Size)
+	assert.Equal(t, 4, m.matchState().label.WinLength)
+	m.join("user1", nil)
+	m.join("user2", nil)
+	m.loop()
+	x, o := m.players()
+	assert.Len(t, m.matchState().board, 16)
+
+	m.loop(m.move(x, 0), m.move(o, 4), m.move(x, 1), m.move(o, 5), m.move(x, 2), m.move(o, 6))
+	assert.True(t, m.matchState().playing, "Expected three in a row not to win on a 4x4 board")
+	m.loop(m.move(x, 3))
+	done := &api.Done{}
+	if assert.NotNil(t, m.dispatcher.last(api.OpCode_OPCODE_DONE)) {
+		assert.NoError(t, proto.Unmarshal(m.dispatcher.last(api.OpCode_OPCODE_DONE).data, done))
+		assert.Equal(t, []int32{0, 1, 2, 3}, done.WinnerPositions)
+	}
+
+	assert.Nil(t, newTestMatch(t, map[string]interface{}{"size": 3, "win_length": 4}).state, "Expected invalid board params to fail the match creation")
+}